@@ -0,0 +1,84 @@
+// Package adapter has helpers for adapters of third party routers, like the ones in adapters
+package adapter
+
+import (
+	"strings"
+
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+// BraceTemplate convert patterns like /books/{id:[0-9]+} of chi and gorilla/mux to path template of OpenAPI.
+// Regexps of variables are returned by name
+func BraceTemplate(pattern string) (string, map[string]string) {
+	var b strings.Builder
+	patterns := make(map[string]string)
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		// Regexps may have braces, so find the matching one
+		depth, end := 0, -1
+		for j := i; j < len(pattern) && end < 0; j++ {
+			switch pattern[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			b.WriteString(pattern[i:])
+			break
+		}
+		name := pattern[i+1 : end]
+		if colon := strings.IndexByte(name, ':'); colon >= 0 {
+			patterns[name[:colon]] = name[colon+1:]
+			name = name[:colon]
+		}
+		b.WriteString("{" + name + "}")
+		i = end
+	}
+	return b.String(), patterns
+}
+
+// ColonTemplate convert patterns like /books/:id and /files/*path of gin and echo to path template of OpenAPI
+func ColonTemplate(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// MergePatterns return patterns of both, b wins for the same name
+func MergePatterns(a, b map[string]string) map[string]string {
+	merged := make(map[string]string, len(a)+len(b))
+	for name, pattern := range a {
+		merged[name] = pattern
+	}
+	for name, pattern := range b {
+		merged[name] = pattern
+	}
+	return merged
+}
+
+// Operation add operation of template to doc, and declare path params not declared yet.
+// Patterns are set to schemas of path params added
+func Operation(doc openapi.Router, method, template string, patterns map[string]string,
+	summary, description string) *openapi.Operation {
+	op := doc.Method(strings.ToLower(method), template, summary, description)
+	declared := len(op.Parameters)
+	op.SyncPathParams()
+	for _, param := range op.Parameters[declared:] {
+		if pattern, ok := patterns[param.Name]; ok && param.Schema != nil {
+			param.Schema.Pattern = "^(?:" + pattern + ")$"
+		}
+	}
+	return op
+}
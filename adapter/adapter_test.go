@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"reflect"
+	"testing"
+
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+func TestBraceTemplate(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		template string
+		patterns map[string]string
+	}{
+		{"/books", "/books", map[string]string{}},
+		{"/{ns}/books/{id}", "/{ns}/books/{id}", map[string]string{}},
+		{"/books/{id:[0-9]{3}}/x", "/books/{id}/x", map[string]string{"id": "[0-9]{3}"}},
+		{"/books/{id", "/books/{id", map[string]string{}},
+	}
+	for _, tC := range testCases {
+		template, patterns := BraceTemplate(tC.pattern)
+		if template != tC.template || !reflect.DeepEqual(patterns, tC.patterns) {
+			t.Errorf("Expect %s %v for %s, got %s %v", tC.template, tC.patterns, tC.pattern, template, patterns)
+		}
+	}
+}
+
+func TestColonTemplate(t *testing.T) {
+	for pattern, expect := range map[string]string{
+		"/books":               "/books",
+		"/:ns/books/:id":       "/{ns}/books/{id}",
+		"/files/*path":         "/files/{path}",
+		"/books/:id/cover.png": "/books/{id}/cover.png",
+	} {
+		if got := ColonTemplate(pattern); got != expect {
+			t.Errorf("Expect %s for %s, got %s", expect, pattern, got)
+		}
+	}
+}
+
+func TestOperation(t *testing.T) {
+	o, err := openapi.New("3.0.0", openapi.Info{Title: "Books", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := openapi.NewRouter(o)
+	doc.Route("/{ns}", func(r openapi.Router) {
+		r.WithPathParam("ns", "Namespace")
+		op := Operation(r, "GET", "/books/{id}", map[string]string{"id": "[0-9]+"}, "Get book", "")
+		if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" {
+			t.Fatalf("Expect only id declared by operation, got %+v", op.Parameters)
+		}
+		if op.Parameters[0].Schema.Pattern != "^(?:[0-9]+)$" {
+			t.Fatalf("Expect pattern of id, got %s", op.Parameters[0].Schema.Pattern)
+		}
+	})
+	if errs := o.Validate(); len(errs) != 1 {
+		t.Fatalf("Expect only missing responses, got %v", errs)
+	}
+}
@@ -0,0 +1,103 @@
+// Package chiopenapi wraps chi router, so that routes are registered with their operations in OpenAPI document
+package chiopenapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	openapi "github.com/tangyanhan/go-openapi"
+	"github.com/tangyanhan/go-openapi/adapter"
+)
+
+// Router registers routes to chi router and document router together
+type Router struct {
+	doc      openapi.Router
+	mux      chi.Router
+	patterns map[string]string
+}
+
+// New create router wrapping chi router and document router
+func New(doc openapi.Router, mux chi.Router) *Router {
+	return &Router{
+		doc:      doc,
+		mux:      mux,
+		patterns: make(map[string]string),
+	}
+}
+
+// Doc return document router, to add params, tags or security shared by routes
+func (r *Router) Doc() openapi.Router {
+	return r.doc
+}
+
+// Mux return the chi router
+func (r *Router) Mux() chi.Router {
+	return r.mux
+}
+
+// Use append middlewares to chi router, it must be called before routes like chi
+func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) *Router {
+	r.mux.Use(middlewares...)
+	return r
+}
+
+// With return router with inline middlewares for the following routes
+func (r *Router) With(middlewares ...func(http.Handler) http.Handler) *Router {
+	return &Router{
+		doc:      r.doc,
+		mux:      r.mux.With(middlewares...),
+		patterns: r.patterns,
+	}
+}
+
+// Route to sub paths, pattern is a chi pattern like /{id:[0-9]+}
+func (r *Router) Route(pattern string, fn func(r *Router)) *Router {
+	template, patterns := adapter.BraceTemplate(pattern)
+	r.mux.Route(pattern, func(mux chi.Router) {
+		r.doc.Route(template, func(doc openapi.Router) {
+			fn(&Router{
+				doc:      doc,
+				mux:      mux,
+				patterns: adapter.MergePatterns(r.patterns, patterns),
+			})
+		})
+	})
+	return r
+}
+
+// Method register handler for method and pattern, and add operation to document
+func (r *Router) Method(method, pattern, summary, description string, h http.Handler) *openapi.Operation {
+	template, patterns := adapter.BraceTemplate(pattern)
+	r.mux.Method(method, pattern, h)
+	return adapter.Operation(r.doc, method, template, adapter.MergePatterns(r.patterns, patterns), summary, description)
+}
+
+// Get register GET route
+func (r *Router) Get(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodGet, pattern, summary, description, h)
+}
+
+// Post register POST route
+func (r *Router) Post(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPost, pattern, summary, description, h)
+}
+
+// Put register PUT route
+func (r *Router) Put(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPut, pattern, summary, description, h)
+}
+
+// Patch register PATCH route
+func (r *Router) Patch(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPatch, pattern, summary, description, h)
+}
+
+// Delete register DELETE route
+func (r *Router) Delete(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodDelete, pattern, summary, description, h)
+}
+
+// Head register HEAD route
+func (r *Router) Head(pattern, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodHead, pattern, summary, description, h)
+}
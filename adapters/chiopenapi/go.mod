@@ -0,0 +1,8 @@
+module github.com/tangyanhan/go-openapi/adapters/chiopenapi
+
+go 1.14
+
+require (
+	github.com/go-chi/chi/v5 v5.0.12
+	github.com/tangyanhan/go-openapi v0.1.0
+)
@@ -0,0 +1,102 @@
+// Package echoopenapi wraps echo router, so that routes are registered with their operations in OpenAPI document
+package echoopenapi
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	openapi "github.com/tangyanhan/go-openapi"
+	"github.com/tangyanhan/go-openapi/adapter"
+)
+
+// Routes is implemented by both *echo.Echo and *echo.Group
+type Routes interface {
+	Add(method, path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) *echo.Route
+	Group(prefix string, middleware ...echo.MiddlewareFunc) *echo.Group
+	Use(middleware ...echo.MiddlewareFunc)
+}
+
+// Router registers routes to echo router and document router together
+type Router struct {
+	doc    openapi.Router
+	routes Routes
+}
+
+// New create router wrapping echo instance or group, and document router
+func New(doc openapi.Router, routes Routes) *Router {
+	return &Router{
+		doc:    doc,
+		routes: routes,
+	}
+}
+
+// Doc return document router, to add params, tags or security shared by routes
+func (r *Router) Doc() openapi.Router {
+	return r.doc
+}
+
+// Echo return the echo instance or group
+func (r *Router) Echo() Routes {
+	return r.routes
+}
+
+// Use append middlewares to echo router
+func (r *Router) Use(middlewares ...echo.MiddlewareFunc) *Router {
+	r.routes.Use(middlewares...)
+	return r
+}
+
+// Route to sub paths with an echo group, prefix is an echo path like /:namespace
+func (r *Router) Route(prefix string, fn func(r *Router), middlewares ...echo.MiddlewareFunc) *Router {
+	group := r.routes.Group(prefix, middlewares...)
+	r.doc.Route(adapter.ColonTemplate(prefix), func(doc openapi.Router) {
+		fn(&Router{
+			doc:    doc,
+			routes: group,
+		})
+	})
+	return r
+}
+
+// Method register handler for method and path, and add operation to document
+func (r *Router) Method(method, path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	r.routes.Add(method, path, h, middlewares...)
+	return adapter.Operation(r.doc, method, adapter.ColonTemplate(path), nil, summary, description)
+}
+
+// Get register GET route
+func (r *Router) Get(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodGet, path, summary, description, h, middlewares...)
+}
+
+// Post register POST route
+func (r *Router) Post(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodPost, path, summary, description, h, middlewares...)
+}
+
+// Put register PUT route
+func (r *Router) Put(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodPut, path, summary, description, h, middlewares...)
+}
+
+// Patch register PATCH route
+func (r *Router) Patch(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodPatch, path, summary, description, h, middlewares...)
+}
+
+// Delete register DELETE route
+func (r *Router) Delete(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodDelete, path, summary, description, h, middlewares...)
+}
+
+// Head register HEAD route
+func (r *Router) Head(path, summary, description string, h echo.HandlerFunc,
+	middlewares ...echo.MiddlewareFunc) *openapi.Operation {
+	return r.Method(http.MethodHead, path, summary, description, h, middlewares...)
+}
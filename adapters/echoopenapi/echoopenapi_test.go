@@ -0,0 +1,46 @@
+package echoopenapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+func TestRouter(t *testing.T) {
+	o, err := openapi.New("3.0.0", openapi.Info{Title: "Books", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	r := New(openapi.NewRouter(o), e)
+	var visited []string
+	r.Route("/:namespace/books", func(r *Router) {
+		r.Get("/:id", "Get book", "", func(c echo.Context) error {
+			return c.String(200, c.Param("namespace")+" "+c.Param("id"))
+		})
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			visited = append(visited, c.Request().URL.Path)
+			return next(c)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/public/books/42", nil))
+	if w.Code != 200 || w.Body.String() != "public 42" {
+		t.Fatalf("Expect routed by echo, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(visited) != 1 {
+		t.Fatalf("Expect group middleware called once, got %v", visited)
+	}
+
+	p, ok := o.Paths["/{namespace}/books/{id}"]
+	if !ok {
+		t.Fatal("Expect path in document")
+	}
+	if op := p.Operation("get"); op == nil || len(op.Parameters) != 2 {
+		t.Fatalf("Expect operation with 2 path params, got %+v", op)
+	}
+}
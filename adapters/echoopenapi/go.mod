@@ -0,0 +1,8 @@
+module github.com/tangyanhan/go-openapi/adapters/echoopenapi
+
+go 1.12
+
+require (
+	github.com/labstack/echo/v4 v4.1.17
+	github.com/tangyanhan/go-openapi v0.1.0
+)
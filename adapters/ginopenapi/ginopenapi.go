@@ -0,0 +1,88 @@
+// Package ginopenapi wraps gin router, so that routes are registered with their operations in OpenAPI document
+package ginopenapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	openapi "github.com/tangyanhan/go-openapi"
+	"github.com/tangyanhan/go-openapi/adapter"
+)
+
+// Router registers routes to gin router and document router together
+type Router struct {
+	doc    openapi.Router
+	routes gin.IRouter
+}
+
+// New create router wrapping gin engine or group, and document router
+func New(doc openapi.Router, routes gin.IRouter) *Router {
+	return &Router{
+		doc:    doc,
+		routes: routes,
+	}
+}
+
+// Doc return document router, to add params, tags or security shared by routes
+func (r *Router) Doc() openapi.Router {
+	return r.doc
+}
+
+// Gin return the gin engine or group
+func (r *Router) Gin() gin.IRouter {
+	return r.routes
+}
+
+// Use append middlewares to gin router, they are called for routes added later
+func (r *Router) Use(middlewares ...gin.HandlerFunc) *Router {
+	r.routes.Use(middlewares...)
+	return r
+}
+
+// Route to sub paths with a gin group, prefix is a gin path like /:namespace
+func (r *Router) Route(prefix string, fn func(r *Router), middlewares ...gin.HandlerFunc) *Router {
+	group := r.routes.Group(prefix, middlewares...)
+	r.doc.Route(adapter.ColonTemplate(prefix), func(doc openapi.Router) {
+		fn(&Router{
+			doc:    doc,
+			routes: group,
+		})
+	})
+	return r
+}
+
+// Method register handlers for method and path, and add operation to document
+func (r *Router) Method(method, path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	r.routes.Handle(method, path, handlers...)
+	return adapter.Operation(r.doc, method, adapter.ColonTemplate(path), nil, summary, description)
+}
+
+// Get register GET route
+func (r *Router) Get(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodGet, path, summary, description, handlers...)
+}
+
+// Post register POST route
+func (r *Router) Post(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPost, path, summary, description, handlers...)
+}
+
+// Put register PUT route
+func (r *Router) Put(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPut, path, summary, description, handlers...)
+}
+
+// Patch register PATCH route
+func (r *Router) Patch(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPatch, path, summary, description, handlers...)
+}
+
+// Delete register DELETE route
+func (r *Router) Delete(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodDelete, path, summary, description, handlers...)
+}
+
+// Head register HEAD route
+func (r *Router) Head(path, summary, description string, handlers ...gin.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodHead, path, summary, description, handlers...)
+}
@@ -0,0 +1,50 @@
+package ginopenapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+func TestRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	o, err := openapi.New("3.0.0", openapi.Info{Title: "Books", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := gin.New()
+	r := New(openapi.NewRouter(o), engine)
+	var visited []string
+	r.Route("/:namespace/books", func(r *Router) {
+		r.Get("/:id", "Get book", "", func(c *gin.Context) {
+			c.String(200, c.Param("namespace")+" "+c.Param("id"))
+		})
+		r.Get("/:id/files/*path", "Get file of book", "", func(c *gin.Context) {
+			c.String(200, c.Param("path"))
+		})
+	}, func(c *gin.Context) {
+		visited = append(visited, c.Request.URL.Path)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/public/books/42", nil))
+	if w.Code != 200 || w.Body.String() != "public 42" {
+		t.Fatalf("Expect routed by gin, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(visited) != 1 {
+		t.Fatalf("Expect group middleware called once, got %v", visited)
+	}
+
+	p, ok := o.Paths["/{namespace}/books/{id}"]
+	if !ok {
+		t.Fatal("Expect path in document")
+	}
+	if op := p.Operation("get"); op == nil || len(op.Parameters) != 2 {
+		t.Fatalf("Expect operation with 2 path params, got %+v", op)
+	}
+	if _, ok := o.Paths["/{namespace}/books/{id}/files/{path}"]; !ok {
+		t.Fatal("Expect wildcard path in document")
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/tangyanhan/go-openapi/adapters/ginopenapi
+
+go 1.12
+
+require (
+	github.com/gin-gonic/gin v1.6.3
+	github.com/tangyanhan/go-openapi v0.1.0
+)
@@ -0,0 +1,8 @@
+module github.com/tangyanhan/go-openapi/adapters/muxopenapi
+
+go 1.12
+
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/tangyanhan/go-openapi v0.1.0
+)
@@ -0,0 +1,93 @@
+// Package muxopenapi wraps gorilla/mux router, so that routes are registered with their operations in OpenAPI document
+package muxopenapi
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	openapi "github.com/tangyanhan/go-openapi"
+	"github.com/tangyanhan/go-openapi/adapter"
+)
+
+// Router registers routes to gorilla/mux router and document router together
+type Router struct {
+	doc      openapi.Router
+	mux      *mux.Router
+	patterns map[string]string
+}
+
+// New create router wrapping gorilla/mux router and document router
+func New(doc openapi.Router, m *mux.Router) *Router {
+	return &Router{
+		doc:      doc,
+		mux:      m,
+		patterns: make(map[string]string),
+	}
+}
+
+// Doc return document router, to add params, tags or security shared by routes
+func (r *Router) Doc() openapi.Router {
+	return r.doc
+}
+
+// Mux return the gorilla/mux router
+func (r *Router) Mux() *mux.Router {
+	return r.mux
+}
+
+// Use append middlewares to gorilla/mux router, they are called for matched routes of the router only
+func (r *Router) Use(middlewares ...mux.MiddlewareFunc) *Router {
+	r.mux.Use(middlewares...)
+	return r
+}
+
+// Route to sub paths with a sub router, prefix is a gorilla/mux template like /{id:[0-9]+}
+func (r *Router) Route(prefix string, fn func(r *Router)) *Router {
+	template, patterns := adapter.BraceTemplate(prefix)
+	sub := r.mux.PathPrefix(prefix).Subrouter()
+	r.doc.Route(template, func(doc openapi.Router) {
+		fn(&Router{
+			doc:      doc,
+			mux:      sub,
+			patterns: adapter.MergePatterns(r.patterns, patterns),
+		})
+	})
+	return r
+}
+
+// Method register handler for method and path, and add operation to document
+func (r *Router) Method(method, path, summary, description string, h http.Handler) *openapi.Operation {
+	template, patterns := adapter.BraceTemplate(path)
+	r.mux.Handle(path, h).Methods(method)
+	return adapter.Operation(r.doc, method, template, adapter.MergePatterns(r.patterns, patterns), summary, description)
+}
+
+// Get register GET route
+func (r *Router) Get(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodGet, path, summary, description, h)
+}
+
+// Post register POST route
+func (r *Router) Post(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPost, path, summary, description, h)
+}
+
+// Put register PUT route
+func (r *Router) Put(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPut, path, summary, description, h)
+}
+
+// Patch register PATCH route
+func (r *Router) Patch(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodPatch, path, summary, description, h)
+}
+
+// Delete register DELETE route
+func (r *Router) Delete(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodDelete, path, summary, description, h)
+}
+
+// Head register HEAD route
+func (r *Router) Head(path, summary, description string, h http.HandlerFunc) *openapi.Operation {
+	return r.Method(http.MethodHead, path, summary, description, h)
+}
@@ -0,0 +1,53 @@
+package muxopenapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+func TestRouter(t *testing.T) {
+	o, err := openapi.New("3.0.0", openapi.Info{Title: "Books", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mux.NewRouter()
+	r := New(openapi.NewRouter(o), m)
+	var visited []string
+	r.Route("/{namespace}/books", func(r *Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				visited = append(visited, req.URL.Path)
+				next.ServeHTTP(w, req)
+			})
+		})
+		r.Get("/{id:[0-9]+}", "Get book", "", func(w http.ResponseWriter, req *http.Request) {
+			vars := mux.Vars(req)
+			w.Write([]byte(vars["namespace"] + " " + vars["id"]))
+		})
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/public/books/42", nil))
+	if w.Code != 200 || w.Body.String() != "public 42" {
+		t.Fatalf("Expect routed by gorilla/mux, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(visited) != 1 {
+		t.Fatalf("Expect group middleware called once, got %v", visited)
+	}
+
+	p, ok := o.Paths["/{namespace}/books/{id}"]
+	if !ok {
+		t.Fatal("Expect path in document")
+	}
+	op := p.Operation("get")
+	if op == nil || len(op.Parameters) != 2 {
+		t.Fatalf("Expect operation with 2 path params, got %+v", op)
+	}
+	if pattern := op.Parameters[1].Schema.Pattern; pattern != "^(?:[0-9]+)$" {
+		t.Fatalf("Expect pattern of id, got %s", pattern)
+	}
+}
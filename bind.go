@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Bind fill struct dst with path, query, header and cookie params of operation op, and JSON body of request r.
+// Params are set to fields named like WithQueryStruct names them, for example query:"page", and their values are
+// converted to types declared by schemas of params. The body is decoded into the field with body tag, or dst itself.
+// Path values are read with PathValues, or matched with path of operation.
+// Failures are returned as *RequestError, with a violation for each param
+func Bind(r *http.Request, op *Operation, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expect pointer to struct to bind, got %T", dst)
+	}
+	rv = rv.Elem()
+	checker := newValueChecker(op.Root())
+	pathValues := PathValues(r)
+	if pathValues == nil {
+		if route, values := newPathMatcher(pathMap{op.path.path: op.path}).match(r.URL.EscapedPath()); route != nil {
+			pathValues = values
+		}
+	}
+
+	var violations []*Violation
+	add := func(in, name, message string) {
+		violations = append(violations, &Violation{
+			In:      in,
+			Name:    name,
+			Message: message,
+		})
+	}
+	// Body goes first, so that params are not overwritten by body fields of the same names
+	if err := bindBody(r, op.RequestBody, rv); err != nil {
+		add("body", "", err.Error())
+	}
+	query := r.URL.Query()
+	for _, param := range append(append([]*Param{}, op.path.Parameters...), op.Parameters...) {
+		param, err := checker.resolveParam(param)
+		if err != nil {
+			add("", "", err.Error())
+			continue
+		}
+		in := string(param.In)
+		value, ok, err := checker.paramValue(r, query, pathValues, param)
+		if err != nil {
+			add(in, param.Name, err.Error())
+			continue
+		}
+		if !ok {
+			if param.Required {
+				add(in, param.Name, "required")
+			}
+			continue
+		}
+		index := paramFieldIndex(rv.Type(), param.In, param.Name)
+		if index == nil {
+			continue
+		}
+		field := fieldByIndex(rv, index)
+		// Strings are kept as they are, like IDs with leading zeros
+		if field.Kind() == reflect.String && len(param.Content) == 0 {
+			values := requestValues(r, query, pathValues, param)
+			if raw, err := styleScalar(param, values[0]); err == nil {
+				field.SetString(raw)
+				continue
+			}
+		}
+		schema, err := checker.paramSchema(param)
+		if err != nil {
+			add(in, param.Name, err.Error())
+			continue
+		}
+		if err := bindParam(field, schema, value); err != nil {
+			add(in, param.Name, err.Error())
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &RequestError{
+		Message:    "failed to bind request",
+		Violations: violations,
+	}
+}
+
+// bindBody decode JSON body into field of struct value rv with body tag, or rv itself.
+// Body of request is replaced so it can still be read
+func bindBody(r *http.Request, body *RequestBody, rv reflect.Value) error {
+	if body == nil || r.Body == nil {
+		return nil
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to read body:%s", err.Error())
+	}
+	if len(data) == 0 {
+		if body.Required {
+			return errors.New("required")
+		}
+		return nil
+	}
+	mimeType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type:%s", err.Error())
+	}
+	if !isJSONMime(mimeType) {
+		return nil
+	}
+	target := rv
+	for i := 0; i < rv.NumField(); i++ {
+		if sf := rv.Type().Field(i); sf.PkgPath == "" {
+			if _, ok := sf.Tag.Lookup("body"); ok {
+				target = rv.Field(i)
+				break
+			}
+		}
+	}
+	if err := json.Unmarshal(data, target.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid JSON:%s", err.Error())
+	}
+	return nil
+}
+
+// bindParam set value of param to field, converted to type of field
+func bindParam(field reflect.Value, schema *Schema, value interface{}) error {
+	ft := field.Type()
+	if s, ok := value.(string); ok && ft == timeType && schema != nil && schema.Format == "date" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return fmt.Errorf("invalid date value %q", s)
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+		return fmt.Errorf("failed to convert %s to %s:%s", raw, ft.String(), err.Error())
+	}
+	return nil
+}
+
+// paramFieldIndex return index of field named as param in location of struct type tv, nil if not found.
+// Fields of embedded structs are searched too
+func paramFieldIndex(tv reflect.Type, in ParamType, name string) []int {
+	for i := 0; i < tv.NumField(); i++ {
+		sf := tv.Field(i)
+		// Fields of unexported embedded structs are settable, but unexported pointers can't be allocated
+		if sf.PkgPath != "" && (!sf.Anonymous || sf.Type.Kind() != reflect.Struct) {
+			continue
+		}
+		fieldName, ok := paramName(sf, in)
+		if !ok {
+			continue
+		}
+		if sf.Anonymous && fieldName == "" {
+			if st := namedStruct(sf.Type); st != nil {
+				if index := paramFieldIndex(st, in, name); index != nil {
+					return append([]int{i}, index...)
+				}
+				continue
+			}
+		}
+		if fieldName == "" {
+			fieldName = sf.Name
+		}
+		// Header names are case insensitive
+		if fieldName == name || in == HeaderParam && strings.EqualFold(fieldName, name) {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+// fieldByIndex return field of struct value rv by index, nil pointers to embedded structs are allocated
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			for rv.Kind() == reflect.Ptr {
+				if rv.IsNil() {
+					rv.Set(reflect.New(rv.Type().Elem()))
+				}
+				rv = rv.Elem()
+			}
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
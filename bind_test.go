@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bookPage struct {
+	Page int `query:"page"`
+}
+
+type bookUpdate struct {
+	bookPage
+	ID      int64     `path:"id"`
+	Tags    []string  `query:"tag"`
+	IDs     []int     `query:"ids"`
+	Since   time.Time `query:"since" format:"date"`
+	TraceID string    `header:"X-Trace-Id"`
+	Session string    `cookie:"session"`
+	Book    *Book     `body:""`
+}
+
+func newBindOperation(t *testing.T) *Operation {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	var op *Operation
+	r.Route("/books/{id}", func(r Router) {
+		r.WithParam(&Param{
+			Name:     "id",
+			In:       PathParam,
+			Required: true,
+			Schema:   &Schema{Type: "integer", Format: "int64"},
+		})
+		op = r.PUT("", "Update book", "Update book").
+			WithQueryStruct(&bookUpdate{}).
+			WithHeaderStruct(&bookUpdate{}).
+			WithCookieStruct(&bookUpdate{}).
+			ReadJSON("Book", true, "book", &Book{}).
+			Returns(200, "Book", "", nil)
+	})
+	return op
+}
+
+func TestParamStructLocations(t *testing.T) {
+	op := newBindOperation(t)
+	// Fields tagged for other locations are left out of each location
+	params := make([]string, 0, len(op.Parameters))
+	for _, param := range op.Parameters {
+		params = append(params, string(param.In)+":"+param.Name)
+	}
+	expect := []string{"query:page", "query:tag", "query:ids", "query:since", "header:X-Trace-Id", "cookie:session"}
+	if !reflect.DeepEqual(expect, params) {
+		t.Fatalf("Expect params %v, got %v", expect, params)
+	}
+}
+
+func TestBind(t *testing.T) {
+	op := newBindOperation(t)
+	req := httptest.NewRequest("PUT", "/books/42?page=2&tag=a&tag=b&ids=1,2&since=2020-01-02", strings.NewReader(`{"name":"Go","author":"Rob","date":"2020-01-02"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace-Id", "007")
+	req.Header.Set("Cookie", "session=abc")
+	var dst bookUpdate
+	if err := Bind(req, op, &dst); err != nil {
+		t.Fatal(err)
+	}
+	expect := bookUpdate{
+		bookPage: bookPage{Page: 2},
+		ID:       42,
+		Tags:     []string{"a", "b"},
+		IDs:      []int{1, 2},
+		Since:    time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		TraceID:  "007",
+		Session:  "abc",
+		Book: &Book{
+			Name:   "Go",
+			Author: "Rob",
+			Date:   "2020-01-02",
+		},
+	}
+	if !reflect.DeepEqual(expect, dst) {
+		t.Fatalf("expect %+v, got %+v", expect, dst)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	op := newBindOperation(t)
+	req := httptest.NewRequest("PUT", "/books/x?page=b&ids=1,c", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+	var dst bookUpdate
+	err := Bind(req, op, &dst)
+	reqErr, ok := err.(*RequestError)
+	if !ok {
+		t.Fatalf("expect *RequestError, got %v", err)
+	}
+	got := make([]string, 0, len(reqErr.Violations))
+	for _, v := range reqErr.Violations {
+		got = append(got, v.In+"."+v.Name)
+	}
+	expect := []string{"body.", "path.id", "query.page", "query.ids"}
+	if !reflect.DeepEqual(expect, got) {
+		t.Fatalf("expect violations %v, got %v: %v", expect, got, err)
+	}
+
+	if err := Bind(req, op, dst); err == nil {
+		t.Fatal("expect error binding to non-pointer")
+	}
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"strings"
+)
+
+// BuildError is a failure recorded while building document with CollectErrors
+type BuildError struct {
+	Method string
+	Path   string
+	// Code is the response code involved, if any
+	Code string
+	Err  error
+}
+
+func (e *BuildError) Error() string {
+	var context []string
+	if e.Method != "" {
+		context = append(context, strings.ToUpper(e.Method))
+	}
+	if e.Path != "" {
+		context = append(context, e.Path)
+	}
+	if e.Code != "" {
+		context = append(context, "code "+e.Code)
+	}
+	if len(context) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(context, " ") + ": " + e.Err.Error()
+}
+
+// BuildErrors are all failures recorded while building document
+type BuildErrors []*BuildError
+
+func (e BuildErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// CollectErrors make builders record failures instead of panic,
+// building goes on and all failures can be retrieved with Err
+func (o *OpenAPI) CollectErrors() *OpenAPI {
+	o.collectErrors = true
+	return o
+}
+
+// Err returns BuildErrors recorded since CollectErrors, or nil if nothing failed
+func (o *OpenAPI) Err() error {
+	if len(o.buildErrors) == 0 {
+		return nil
+	}
+	return o.buildErrors
+}
+
+// fail record err with CollectErrors, otherwise panic with err, so the message has the same context
+func (o *OpenAPI) fail(err *BuildError) {
+	if !o.collectErrors {
+		panic(err)
+	}
+	o.buildErrors = append(o.buildErrors, err)
+}
+
+// fail record err with context of operation
+func (o *Operation) fail(code string, err error) {
+	o.Root().fail(&BuildError{
+		Method: o.method,
+		Path:   o.path.path,
+		Code:   code,
+		Err:    err,
+	})
+}
@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"testing"
+)
+
+type badSchemaDoc struct{}
+
+func (b badSchemaDoc) SchemaDoc() int {
+	return 0
+}
+
+func TestCollectErrors(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.CollectErrors()
+	r := NewRouter(o)
+	r.Route("/books", func(r Router) {
+		r.GET("", "List books", "List books").
+			Returns(200, "Book content", "bookArray", []*Book{}).
+			Returns(200, "Book content", "bookArray", []*Book{}).
+			Returns(400, "Bad schema", "bad", &struct {
+				Bad badSchemaDoc `json:"bad"`
+			}{})
+		r.GET("", "List books again", "List books").
+			WithParam(&Param{Name: "q", In: "body"}).
+			Returns(200, "Book content", "bookArray", []*Book{})
+	})
+	o.AddPath("/books", "Books", "Books again")
+	param := o.GetParam("missing")
+	oneOf := (&Schema{root: o}).WithOneOf(1, &Book{}, "book", &Book{})
+
+	expect := []string{
+		"GET /books code 200: response already exists",
+		"GET /books code 400: error parsing field Bad:invalid SchemaDoc method given",
+		"GET /books: operation for path /books already exists method get",
+		"GET /books: invalid param in body",
+		"/books: path already exists:/books",
+		"failed to find param with key:missing",
+		"invalid oneOf key:1",
+	}
+	errs, ok := o.Err().(BuildErrors)
+	if !ok {
+		t.Fatalf("expect BuildErrors, got %v", o.Err())
+	}
+	if len(errs) != len(expect) {
+		t.Fatalf("Expect %d errors, got %d:\n%v", len(expect), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expect[i] {
+			t.Errorf("Expect:\n%s\nGot:\n%s", expect[i], err.Error())
+		}
+	}
+	if param.Ref != "#/components/parameters/missing" {
+		t.Fatal("unexpected param", param.Ref)
+	}
+	if len(oneOf.OneOf) != 1 {
+		t.Fatalf("Expect oneOf of valid pair only, got %v", oneOf.OneOf)
+	}
+	op := o.Paths["/books"].operations["get"]
+	if op.Summary != "List books" || len(op.Parameters) != 0 {
+		t.Fatal("detached operation should not change the document")
+	}
+}
+
+func TestPanicByDefault(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err, ok := recover().(*BuildError)
+		if !ok {
+			t.Fatal("expect panic of BuildError without CollectErrors")
+		}
+		if err.Error() != "GET /books code 200: response already exists" {
+			t.Fatalf("expect located message, got %s", err.Error())
+		}
+		if o.Err() != nil {
+			t.Fatal("nothing should be recorded without CollectErrors")
+		}
+	}()
+	o.AddPath("/books", "Books", "Books").AddOperation("get").
+		Returns(200, "Book", "book", &Book{}).
+		Returns(200, "Book", "book", &Book{})
+}
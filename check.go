@@ -0,0 +1,362 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// schemaViolation is a value not matching its schema, pointer is where it is in the value
+type schemaViolation struct {
+	pointer string
+	message string
+}
+
+// valueChecker check decoded JSON values against schemas of a document.
+// It's safe for concurrent use
+type valueChecker struct {
+	mu       sync.Mutex
+	resolver *Resolver
+	patterns map[string]*regexp.Regexp
+}
+
+func newValueChecker(o *OpenAPI) *valueChecker {
+	return &valueChecker{
+		resolver: o.Resolver(""),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+}
+
+func (c *valueChecker) resolve(s *Schema) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resolver.Schema(s)
+}
+
+func (c *valueChecker) resolveParam(p *Param) (*Param, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resolver.Param(p)
+}
+
+func (c *valueChecker) resolveResponse(resp *Response) (*Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resolver.Response(resp)
+}
+
+func (c *valueChecker) pattern(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	re, ok := c.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+		c.patterns[pattern] = re
+	}
+	return re, nil
+}
+
+// check value v decoded from JSON against schema s
+func (c *valueChecker) check(s *Schema, v interface{}, pointer string) []schemaViolation {
+	if s == nil {
+		return nil
+	}
+	s, err := c.resolve(s)
+	if err != nil {
+		return []schemaViolation{{pointer, err.Error()}}
+	}
+	var violations []schemaViolation
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, schemaViolation{pointer, fmt.Sprintf(format, args...)})
+	}
+
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		if s.Type != "" {
+			fail("null is not allowed")
+			return violations
+		}
+		// Untyped schemas like wrappers of refs reject null by their sub schemas
+		return c.checkCombined(s, v, pointer)
+	}
+	if s.Type != "" && !isType(s.Type, v) {
+		fail("expect %s, got %s", s.Type, typeOf(v))
+		return violations
+	}
+	if len(s.Enum) != 0 && !inEnum(s.Enum, v) {
+		fail("value %v is not one of %v", v, s.Enum)
+	}
+
+	switch value := v.(type) {
+	case string:
+		length := int64(utf8.RuneCountInString(value))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("length %d is less than minLength %d", length, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("length %d is greater than maxLength %d", length, *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := c.pattern(s.Pattern)
+			if err != nil {
+				fail("invalid pattern %s:%s", s.Pattern, err.Error())
+			} else if !re.MatchString(value) {
+				fail("value does not match pattern %s", s.Pattern)
+			}
+		}
+		if err := checkStringFormat(s.Format, value); err != nil {
+			fail("invalid %s:%s", s.Format, err.Error())
+		}
+	case map[string]interface{}:
+		violations = append(violations, c.checkObject(s, value, pointer)...)
+	case []interface{}:
+		length := int64(len(value))
+		if s.MinItems != nil && length < *s.MinItems {
+			fail("%d items are less than minItems %d", length, *s.MinItems)
+		}
+		if s.MaxItems != nil && length > *s.MaxItems {
+			fail("%d items are more than maxItems %d", length, *s.MaxItems)
+		}
+		if s.UniqueItems {
+			if i, j, ok := duplicateItems(value); ok {
+				fail("items %d and %d are equal", i, j)
+			}
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				violations = append(violations, c.check(s.Items, item, pointerJoin(pointer, strconv.Itoa(i)))...)
+			}
+		}
+	default:
+		if f, ok := toFloat(v); ok {
+			if min, ok := toFloat(s.Minimum); ok && (f < min || s.ExclusiveMinimum && f == min) {
+				if s.ExclusiveMinimum {
+					fail("value %v is not greater than exclusive minimum %v", v, s.Minimum)
+				} else {
+					fail("value %v is less than minimum %v", v, s.Minimum)
+				}
+			}
+			if max, ok := toFloat(s.Maximum); ok && (f > max || s.ExclusiveMaximum && f == max) {
+				if s.ExclusiveMaximum {
+					fail("value %v is not less than exclusive maximum %v", v, s.Maximum)
+				} else {
+					fail("value %v is greater than maximum %v", v, s.Maximum)
+				}
+			}
+			if m, ok := toFloat(s.MultipleOf); ok && m > 0 {
+				if q := f / m; q != math.Trunc(q) {
+					fail("value %v is not multiple of %v", v, s.MultipleOf)
+				}
+			}
+			if s.Format == "int32" && (f < math.MinInt32 || f > math.MaxInt32) {
+				fail("value %v overflows int32", v)
+			}
+		}
+	}
+
+	return append(violations, c.checkCombined(s, v, pointer)...)
+}
+
+// checkCombined checks value v against allOf, anyOf, oneOf and not of schema s
+func (c *valueChecker) checkCombined(s *Schema, v interface{}, pointer string) []schemaViolation {
+	var violations []schemaViolation
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, schemaViolation{pointer, fmt.Sprintf(format, args...)})
+	}
+	for _, sub := range s.AllOf {
+		violations = append(violations, c.check(sub, v, pointer)...)
+	}
+	if len(s.AnyOf) != 0 && c.countMatches(s.AnyOf, v, pointer) == 0 {
+		fail("value matches none of anyOf")
+	}
+	if len(s.OneOf) != 0 {
+		if n := c.countMatches(s.OneOf, v, pointer); n != 1 {
+			fail("value matches %d schemas of oneOf, expect exactly 1", n)
+		}
+	}
+	if s.Not != nil && len(c.check(s.Not, v, pointer)) == 0 {
+		fail("value should not match schema of not")
+	}
+	return violations
+}
+
+func (c *valueChecker) checkObject(s *Schema, obj map[string]interface{}, pointer string) []schemaViolation {
+	var violations []schemaViolation
+	count := int64(len(obj))
+	if s.MinProperties != nil && count < *s.MinProperties {
+		violations = append(violations, schemaViolation{pointer, fmt.Sprintf("%d properties are less than minProperties %d", count, *s.MinProperties)})
+	}
+	if s.MaxProperties != nil && count > *s.MaxProperties {
+		violations = append(violations, schemaViolation{pointer, fmt.Sprintf("%d properties are more than maxProperties %d", count, *s.MaxProperties)})
+	}
+	if s.Required != nil {
+		for _, name := range s.Required.Properties {
+			if _, ok := obj[name]; !ok {
+				violations = append(violations, schemaViolation{pointer, "missing required property " + name})
+			}
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop, ok := s.Properties[key]
+		if !ok {
+			prop = s.AdditionalProperties
+		}
+		violations = append(violations, c.check(prop, obj[key], pointerJoin(pointer, key))...)
+	}
+	return violations
+}
+
+func (c *valueChecker) countMatches(schemas []*Schema, v interface{}, pointer string) int {
+	var n int
+	for _, s := range schemas {
+		if len(c.check(s, v, pointer)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func isType(typ string, v interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "number":
+		_, ok := toFloat(v)
+		return ok
+	case "integer":
+		f, ok := toFloat(v)
+		return ok && f == math.Trunc(f)
+	default:
+		return true
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		if _, ok := toFloat(v); ok {
+			return "number"
+		}
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	f, isNumber := toFloat(v)
+	for _, e := range enum {
+		if isNumber {
+			if ef, ok := toFloat(e); ok && ef == f {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func checkStringFormat(format, v string) error {
+	var err error
+	switch format {
+	case "date":
+		_, err = time.Parse("2006-01-02", v)
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, v)
+	case "email":
+		_, err = mail.ParseAddress(v)
+	case "uri":
+		var u *url.URL
+		if u, err = url.Parse(v); err == nil && !u.IsAbs() {
+			err = errors.New("not an absolute URI")
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(v) {
+			err = errors.New("not a UUID")
+		}
+	case "ipv4":
+		if ip := net.ParseIP(v); ip == nil || ip.To4() == nil {
+			err = errors.New("not an IPv4 address")
+		}
+	case "ipv6":
+		if ip := net.ParseIP(v); ip == nil || ip.To4() != nil {
+			err = errors.New("not an IPv6 address")
+		}
+	}
+	return err
+}
+
+// duplicateItems return indexes of the first equal items
+func duplicateItems(items []interface{}) (int, int, bool) {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,47 @@
+// Command openapi-comments writes a Go file registering doc comments of packages,
+// so that schemas are described by them without source on disk. Use it with go generate:
+//
+//	//go:generate go run github.com/tangyanhan/go-openapi/cmd/openapi-comments -o openapi_comments.go . ./models
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	openapi "github.com/tangyanhan/go-openapi"
+)
+
+func main() {
+	pkgName := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of generated file, defaults to $GOPACKAGE set by go generate")
+	output := flag.String("o", "", "output file, defaults to stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-pkg name] [-o file] dir...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	if *pkgName == "" {
+		fmt.Fprintln(os.Stderr, "package name is required")
+		os.Exit(2)
+	}
+
+	var buf bytes.Buffer
+	if err := openapi.WriteComments(&buf, *pkgName, dirs...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := ioutil.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
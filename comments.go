@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// commentRegistry keeps doc comments of types and fields, keyed by package path, then by names like Book or Book.Name
+var commentRegistry = struct {
+	sync.RWMutex
+	comments map[string]map[string]string
+}{
+	comments: make(map[string]map[string]string),
+}
+
+// RegisterComments register doc comments of package pkgPath, used as descriptions of schemas and properties.
+// Keys of comments are type names like "Book", and field names like "Book.Name".
+// It's called by files written by WriteComments, so that source is not needed at runtime
+func RegisterComments(pkgPath string, comments map[string]string) {
+	commentRegistry.Lock()
+	defer commentRegistry.Unlock()
+	registered, ok := commentRegistry.comments[pkgPath]
+	if !ok {
+		registered = make(map[string]string, len(comments))
+		commentRegistry.comments[pkgPath] = registered
+	}
+	for name, comment := range comments {
+		registered[name] = comment
+	}
+}
+
+// LoadComments parse Go source of package directories and register their doc comments.
+// Import paths of packages are found with go.mod of their module
+func LoadComments(dirs ...string) error {
+	for _, dir := range dirs {
+		pkgPath, comments, err := parseComments(dir)
+		if err != nil {
+			return err
+		}
+		RegisterComments(pkgPath, comments)
+	}
+	return nil
+}
+
+// WriteComments write Go source of package pkgName, which registers doc comments of package directories on init.
+// It's used by go generate, see cmd/openapi-comments
+func WriteComments(w io.Writer, pkgName string, dirs ...string) error {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by openapi-comments. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	buf.WriteString("import openapi \"github.com/tangyanhan/go-openapi\"\n\n")
+	buf.WriteString("func init() {\n")
+	for _, dir := range dirs {
+		pkgPath, comments, err := parseComments(dir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "openapi.RegisterComments(%s, map[string]string{\n", strconv.Quote(pkgPath))
+		names := make([]string, 0, len(comments))
+		for name := range comments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&buf, "%s: %s,\n", strconv.Quote(name), strconv.Quote(comments[name]))
+		}
+		buf.WriteString("})\n")
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format comments:%s", err.Error())
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// parseComments collect doc comments of types and their fields in package directory dir, test files excluded
+func parseComments(dir string) (pkgPath string, comments map[string]string, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s:%s", dir, err.Error())
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expect 1 package in %s, got %d", dir, len(pkgs))
+	}
+	var pkgName string
+	var pkg *ast.Package
+	for name, p := range pkgs {
+		pkgName, pkg = name, p
+	}
+	if pkgName == "main" {
+		pkgPath = "main"
+	} else if pkgPath, err = importPath(dir); err != nil {
+		return "", nil, err
+	}
+
+	comments = make(map[string]string)
+	for _, t := range doc.New(pkg, pkgPath, doc.AllDecls).Types {
+		if text := strings.TrimSpace(t.Doc); text != "" {
+			comments[t.Name] = text
+		}
+		for _, spec := range t.Decl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != t.Name {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range structType.Fields.List {
+				text := strings.TrimSpace(field.Doc.Text())
+				if text == "" {
+					text = strings.TrimSpace(field.Comment.Text())
+				}
+				if text == "" {
+					continue
+				}
+				for _, name := range field.Names {
+					comments[t.Name+"."+name.Name] = text
+				}
+			}
+		}
+	}
+	return pkgPath, comments, nil
+}
+
+// importPath find import path of package directory dir with go.mod of its module
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for modDir := abs; ; modDir = filepath.Dir(modDir) {
+		module, err := modulePath(filepath.Join(modDir, "go.mod"))
+		if err == nil {
+			rel, err := filepath.Rel(modDir, abs)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if filepath.Dir(modDir) == modDir {
+			return "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+	}
+}
+
+// modulePath read module path from go.mod file
+func modulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted, nil
+		}
+		return fields[1], nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no module path in " + goMod)
+}
+
+// comment return registered doc comment of type, or field of type if field is not empty
+func comment(pkgPath, typeName, field string) string {
+	if typeName == "" {
+		return ""
+	}
+	name := typeName
+	if field != "" {
+		name += "." + field
+	}
+	commentRegistry.RLock()
+	defer commentRegistry.RUnlock()
+	return commentRegistry.comments[pkgPath][name]
+}
@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const commentedSource = `package models
+
+// Order is an order of books
+type Order struct {
+	// ID of order
+	ID    string ` + "`json:\"id\"`" + `
+	Total int    ` + "`json:\"total\"`" + ` // Total price in cents
+	Note  string ` + "`json:\"note\"`" + `
+}
+
+type (
+	// Status of order
+	Status string
+)
+`
+
+func TestParseComments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkgDir := filepath.Join(dir, "models")
+	if err := os.Mkdir(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(dir, "go.mod"):            "module example.com/shop\n\ngo 1.12\n",
+		filepath.Join(pkgDir, "order.go"):       commentedSource,
+		filepath.Join(pkgDir, "order_test.go"):  "package models\n\n// Fake is ignored\ntype Fake struct{}\n",
+		filepath.Join(dir, "main.go"):           "package main\n\n// Config of command\ntype Config struct{}\n",
+		filepath.Join(dir, "invalid", "bad.go"): "not go source",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkgPath, comments, err := parseComments(pkgDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"Order":       "Order is an order of books",
+		"Order.ID":    "ID of order",
+		"Order.Total": "Total price in cents",
+		"Status":      "Status of order",
+	}
+	if pkgPath != "example.com/shop/models" || !reflect.DeepEqual(comments, expect) {
+		t.Fatalf("Expect %v of example.com/shop/models, got %v of %s", expect, comments, pkgPath)
+	}
+	if pkgPath, _, err := parseComments(dir); err != nil || pkgPath != "main" {
+		t.Fatalf("Expect package main, got %s, %v", pkgPath, err)
+	}
+	if _, _, err := parseComments(filepath.Join(dir, "invalid")); err == nil {
+		t.Fatal("Expect error for invalid source")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteComments(&buf, "api", pkgDir); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	if formatted, err := format.Source(buf.Bytes()); err != nil || string(formatted) != src {
+		t.Fatalf("Expect formatted source, got %v:\n%s", err, src)
+	}
+	for _, s := range []string{"package api\n", `openapi.RegisterComments("example.com/shop/models", map[string]string{`, `"Order.Total": "Total price in cents",`} {
+		if !strings.Contains(src, s) {
+			t.Fatalf("Expect %s in:\n%s", s, src)
+		}
+	}
+}
+
+// commented has descriptions from registered comments
+type commented struct {
+	Name   string `json:"name"`
+	Title  string `json:"title" description:"Title from tag"`
+	Book   Book   `json:"book"`
+	person `json:"-"`
+	audit
+}
+
+func TestComments(t *testing.T) {
+	pkgPath := reflect.TypeOf(commented{}).PkgPath()
+	RegisterComments(pkgPath, map[string]string{
+		"commented":       "commented has descriptions from registered comments",
+		"commented.Name":  "Name from comment",
+		"commented.Title": "Title from comment",
+		"commented.Book":  "Book from comment",
+		"audit.Created":   "Created from comment",
+	})
+	defer func() {
+		commentRegistry.Lock()
+		delete(commentRegistry.comments, pkgPath)
+		commentRegistry.Unlock()
+	}()
+
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("commented", &commented{})
+	raw, err := json.Marshal(o.Components.Schemas["commented"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"object","properties":{"name":{"type":"string","description":"Name from comment"},` +
+		`"title":{"type":"string","description":"Title from tag"},` +
+		`"book":{"allOf":[{"$ref":"#/components/schemas/go-openapi.Book"}],"description":"Book from comment"},` +
+		`"created":{"type":"string","description":"Created from comment"},"updated":{"type":"string"}},` +
+		`"description":"commented has descriptions from registered comments","required":["name","title","book","created"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+}
@@ -0,0 +1,150 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// DocsViewer is the HTML viewer of docs handler
+type DocsViewer string
+
+// Viewers of docs handler
+const (
+	// BuiltinViewer is a plain viewer with inline scripts, it needs no assets
+	BuiltinViewer DocsViewer = ""
+	// SwaggerUI loads swagger-ui.css and swagger-ui-bundle.js of swagger-ui-dist from assets
+	SwaggerUI DocsViewer = "swagger-ui"
+	// Redoc loads redoc.standalone.js of redoc from assets
+	Redoc DocsViewer = "redoc"
+	// RapiDoc loads rapidoc-min.js of rapidoc from assets
+	RapiDoc DocsViewer = "rapidoc"
+)
+
+// DocsOptions options for docs handler
+type DocsOptions struct {
+	// Title of the viewer page, title of document by default
+	Title string
+	// BasePath is where the handler is mounted, like /docs
+	BasePath string
+	// ExpandTags are tags expanded by default, all tags are expanded if it's empty. Only for BuiltinViewer
+	ExpandTags []string
+	// Regenerate the spec on each request, otherwise it's generated once when handler is created
+	Regenerate bool
+	// Viewer of the document, BuiltinViewer by default
+	Viewer DocsViewer
+	// Assets are files of the viewer bundle served at {BasePath}/assets/, like the dist directory of swagger-ui-dist
+	// embedded in the binary. The bundles are not shipped with this package, so they must be supplied
+	// for viewers other than BuiltinViewer
+	Assets http.FileSystem
+}
+
+// DocsHandler serves the document at {BasePath}/openapi.json and {BasePath}/openapi.yaml,
+// and an HTML viewer at {BasePath}/. The viewer loads nothing from other hosts, so it works offline.
+// ErrNoDocsAssets is returned for viewers other than BuiltinViewer without Assets
+func DocsHandler(o *OpenAPI, opts DocsOptions) (http.Handler, error) {
+	tmpl, ok := docsTemplates[opts.Viewer]
+	if !ok {
+		return nil, fmt.Errorf("unknown docs viewer:%s", opts.Viewer)
+	}
+	if opts.Viewer != BuiltinViewer && opts.Assets == nil {
+		return nil, fmt.Errorf("%s:%s", ErrNoDocsAssets.Error(), opts.Viewer)
+	}
+	h := &docsHandler{
+		doc:      o,
+		opts:     opts,
+		basePath: strings.TrimSuffix(opts.BasePath, "/"),
+	}
+	if !opts.Regenerate {
+		h.json, h.jsonErr = o.JSON()
+		h.yaml, h.yamlErr = o.YAML()
+	}
+	title := opts.Title
+	if title == "" {
+		title = o.Info.Title
+	}
+	expandTags := opts.ExpandTags
+	if expandTags == nil {
+		expandTags = []string{}
+	}
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, map[string]interface{}{
+		"Title":      title,
+		"ExpandTags": expandTags,
+	}); err != nil {
+		return nil, err
+	}
+	h.page = page.Bytes()
+	if opts.Assets != nil {
+		h.assets = http.StripPrefix(h.basePath+"/assets", http.FileServer(opts.Assets))
+	}
+	return h, nil
+}
+
+var docsTemplates = map[DocsViewer]*template.Template{
+	BuiltinViewer: template.Must(template.New("docs").Parse(docsHTML)),
+	SwaggerUI:     template.Must(template.New("swagger-ui").Parse(swaggerUIHTML)),
+	Redoc:         template.Must(template.New("redoc").Parse(redocHTML)),
+	RapiDoc:       template.Must(template.New("rapidoc").Parse(rapiDocHTML)),
+}
+
+type docsHandler struct {
+	doc      *OpenAPI
+	opts     DocsOptions
+	basePath string
+	page     []byte
+	assets   http.Handler
+	json     []byte
+	jsonErr  error
+	yaml     []byte
+	yamlErr  error
+}
+
+func (h *docsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, h.basePath) {
+		http.NotFound(w, r)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, h.basePath)
+	if strings.HasPrefix(path, "/assets/") && h.assets != nil {
+		h.assets.ServeHTTP(w, r)
+		return
+	}
+	switch path {
+	case "":
+		http.Redirect(w, r, h.basePath+"/", http.StatusFound)
+	case "/", "/index.html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(h.page)
+	case "/openapi.json":
+		data, err := h.json, h.jsonErr
+		if h.opts.Regenerate {
+			data, err = h.doc.JSON()
+		}
+		writeSpec(w, MimeJSON, data, err)
+	case "/openapi.yaml":
+		data, err := h.yaml, h.yamlErr
+		if h.opts.Regenerate {
+			data, err = h.doc.YAML()
+		}
+		writeSpec(w, MimeYAML, data, err)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeSpec(w http.ResponseWriter, mimeType string, data []byte, err error) {
+	if err != nil {
+		http.Error(w, "failed to generate document:"+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Write(data)
+}
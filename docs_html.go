@@ -0,0 +1,317 @@
+package openapi
+
+// docsHTML is the viewer page served by DocsHandler, it loads openapi.json next to it.
+// All styles and scripts are inline, so that it works offline
+const docsHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; background: #fafafa; }
+header { padding: 16px 24px; background: #1f2933; color: #fff; display: flex; align-items: baseline; gap: 12px; flex-wrap: wrap; }
+header h1 { margin: 0; font-size: 22px; }
+header #version { opacity: .7; }
+header nav { margin-left: auto; }
+header nav a { color: #9fd3ff; margin-left: 12px; }
+main { max-width: 1100px; margin: 0 auto; padding: 16px 24px 48px; }
+details.tag { margin: 16px 0; }
+details.tag > summary h2 { display: inline; font-size: 20px; }
+details.operation { margin: 8px 0; border: 1px solid #ddd; border-radius: 4px; background: #fff; }
+details.operation > summary { padding: 8px; cursor: pointer; display: flex; align-items: center; gap: 12px; }
+details.operation.deprecated > summary { opacity: .6; text-decoration: line-through; }
+.method { display: inline-block; min-width: 64px; padding: 4px 0; border-radius: 3px; color: #fff; font-weight: bold; font-size: 12px; text-align: center; background: #616e7c; }
+.method.get { background: #2f80ed; }
+.method.post { background: #27ae60; }
+.method.put { background: #f2994a; }
+.method.patch { background: #9b51e0; }
+.method.delete { background: #eb5757; }
+.path { font-weight: bold; }
+.summary { color: #555; }
+.body { padding: 0 16px 16px; border-top: 1px solid #eee; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+pre.schema { margin: 4px 0 8px; padding: 8px; background: #f4f5f7; border-radius: 3px; overflow-x: auto; }
+.response, .media { margin: 6px 0; }
+.error { color: #eb5757; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1><span id="version"></span>
+<nav><a href="openapi.json">openapi.json</a><a href="openapi.yaml">openapi.yaml</a></nav>
+</header>
+<main id="main"><p>Loading...</p></main>
+<script>
+(function () {
+	"use strict";
+	var expandTags = {{.ExpandTags}};
+	var methods = ["get", "put", "post", "delete", "options", "head", "patch", "trace"];
+	var main = document.getElementById("main");
+	var spec;
+
+	function el(tag, attrs, children) {
+		var e = document.createElement(tag);
+		Object.keys(attrs || {}).forEach(function (k) {
+			if (k === "text") {
+				e.textContent = attrs[k];
+			} else {
+				e.setAttribute(k, attrs[k]);
+			}
+		});
+		(children || []).forEach(function (c) {
+			if (c) {
+				e.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+			}
+		});
+		return e;
+	}
+
+	// resolve local refs like #/components/schemas/Book
+	function resolve(obj) {
+		for (var i = 0; obj && obj.$ref && obj.$ref.indexOf("#/") === 0 && i < 32; i++) {
+			var target = spec;
+			obj.$ref.slice(2).split("/").forEach(function (token) {
+				token = token.replace(/~1/g, "/").replace(/~0/g, "~");
+				target = target ? target[token] : undefined;
+			});
+			obj = target;
+		}
+		return obj;
+	}
+
+	function refName(ref) {
+		return ref.slice(ref.lastIndexOf("/") + 1);
+	}
+
+	// schemaText renders schema like a JSON model, required properties are marked with *
+	function schemaText(s, indent, seen) {
+		if (!s) {
+			return "any";
+		}
+		if (s.$ref) {
+			var name = refName(s.$ref);
+			if (seen.indexOf(s.$ref) >= 0) {
+				return name;
+			}
+			return name + " " + schemaText(resolve(s), indent, seen.concat([s.$ref]));
+		}
+		var combined = ["allOf", "oneOf", "anyOf"].filter(function (k) {
+			return s[k];
+		}).map(function (k) {
+			return k + "(" + s[k].map(function (sub) {
+				return schemaText(sub, indent, seen);
+			}).join(" | ") + ")";
+		});
+		if (combined.length) {
+			return combined.join(" ");
+		}
+		if (s.type === "array") {
+			return "[" + schemaText(s.items, indent, seen) + "]";
+		}
+		if (s.type === "object" || s.properties) {
+			var pad = new Array(indent + 1).join("  ");
+			var required = Array.isArray(s.required) ? s.required : [];
+			var lines = Object.keys(s.properties || {}).map(function (name) {
+				var mark = required.indexOf(name) >= 0 ? "*" : "";
+				return pad + "  " + name + mark + ": " + schemaText(s.properties[name], indent + 1, seen);
+			});
+			if (s.additionalProperties && typeof s.additionalProperties === "object") {
+				lines.push(pad + "  [key]: " + schemaText(s.additionalProperties, indent + 1, seen));
+			}
+			return lines.length ? "{\n" + lines.join(",\n") + "\n" + pad + "}" : "{}";
+		}
+		var text = s.type || "any";
+		if (s.format) {
+			text += "(" + s.format + ")";
+		}
+		if (s.enum) {
+			text += " enum[" + s.enum.join(", ") + "]";
+		}
+		return text;
+	}
+
+	function schemaBlock(s) {
+		return el("pre", {"class": "schema", text: schemaText(s, 0, [])});
+	}
+
+	function contentBlock(content) {
+		return el("div", {}, Object.keys(content || {}).map(function (mime) {
+			return el("div", {"class": "media"}, [el("code", {text: mime}), schemaBlock(content[mime].schema)]);
+		}));
+	}
+
+	function paramsTable(params) {
+		var head = el("tr", {}, ["Name", "In", "Schema", "Description"].map(function (h) {
+			return el("th", {text: h});
+		}));
+		return el("table", {}, [head].concat(params.map(function (p) {
+			p = resolve(p) || {};
+			return el("tr", {}, [
+				el("td", {}, [el("code", {text: p.name + (p.required ? "*" : "")})]),
+				el("td", {text: p.in || ""}),
+				el("td", {text: p.schema ? schemaText(p.schema, 0, []) : ""}),
+				el("td", {text: p.description || ""})
+			]);
+		})));
+	}
+
+	function operation(path, method, op, pathParams) {
+		var body = el("div", {"class": "body"});
+		if (op.description) {
+			body.appendChild(el("p", {text: op.description}));
+		}
+		var params = (pathParams || []).concat(op.parameters || []);
+		if (params.length) {
+			body.appendChild(el("h4", {text: "Parameters"}));
+			body.appendChild(paramsTable(params));
+		}
+		if (op.requestBody) {
+			var requestBody = resolve(op.requestBody) || {};
+			body.appendChild(el("h4", {text: "Request Body" + (requestBody.required ? " *" : "")}));
+			if (requestBody.description) {
+				body.appendChild(el("p", {text: requestBody.description}));
+			}
+			body.appendChild(contentBlock(requestBody.content));
+		}
+		body.appendChild(el("h4", {text: "Responses"}));
+		Object.keys(op.responses || {}).sort().forEach(function (code) {
+			var resp = resolve(op.responses[code]) || {};
+			body.appendChild(el("div", {"class": "response"}, [
+				el("strong", {text: code}), " " + (resp.description || ""), contentBlock(resp.content)
+			]));
+		});
+		var summary = el("summary", {}, [
+			el("span", {"class": "method " + method, text: method.toUpperCase()}),
+			el("code", {"class": "path", text: path}),
+			el("span", {"class": "summary", text: op.summary || ""})
+		]);
+		return el("details", {"class": "operation" + (op.deprecated ? " deprecated" : "")}, [summary, body]);
+	}
+
+	function section(name, children) {
+		var d = el("details", {"class": "tag"}, [el("summary", {}, [el("h2", {text: name})])].concat(children));
+		d.open = expandTags.length === 0 || expandTags.indexOf(name) >= 0;
+		return d;
+	}
+
+	function render() {
+		main.textContent = "";
+		var info = spec.info || {};
+		document.getElementById("version").textContent = info.version || "";
+		if (info.description) {
+			main.appendChild(el("p", {text: info.description}));
+		}
+		if (spec.servers && spec.servers.length) {
+			main.appendChild(el("p", {}, ["Servers: "].concat(spec.servers.map(function (s) {
+				return el("code", {text: s.url + " "});
+			}))));
+		}
+
+		var groups = {}, order = [];
+		(spec.tags || []).forEach(function (t) {
+			groups[t.name] = [];
+			order.push(t.name);
+		});
+		Object.keys(spec.paths || {}).forEach(function (path) {
+			var item = spec.paths[path];
+			methods.forEach(function (method) {
+				var op = item[method];
+				if (!op) {
+					return;
+				}
+				(op.tags && op.tags.length ? op.tags : ["default"]).forEach(function (tag) {
+					if (!groups[tag]) {
+						groups[tag] = [];
+						order.push(tag);
+					}
+					groups[tag].push(operation(path, method, op, item.parameters));
+				});
+			});
+		});
+		order.forEach(function (tag) {
+			if (groups[tag].length) {
+				main.appendChild(section(tag, groups[tag]));
+			}
+		});
+
+		var schemas = (spec.components || {}).schemas || {};
+		var names = Object.keys(schemas).sort();
+		if (names.length) {
+			main.appendChild(section("Schemas", names.map(function (name) {
+				return el("div", {}, [el("strong", {text: name}), schemaBlock(schemas[name])]);
+			})));
+		}
+	}
+
+	var xhr = new XMLHttpRequest();
+	xhr.open("GET", "openapi.json");
+	xhr.onload = function () {
+		try {
+			if (xhr.status !== 200) {
+				throw new Error(xhr.status + " " + xhr.responseText);
+			}
+			spec = JSON.parse(xhr.responseText);
+			render();
+		} catch (e) {
+			main.textContent = "";
+			main.appendChild(el("p", {"class": "error", text: "Failed to load openapi.json: " + e.message}));
+		}
+	};
+	xhr.send();
+})();
+</script>
+</body>
+</html>
+`
+
+// swaggerUIHTML is the page of SwaggerUI viewer, the bundle is served from assets next to it
+const swaggerUIHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="assets/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="assets/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui", docExpansion: "list"});
+</script>
+</body>
+</html>
+`
+
+// redocHTML is the page of Redoc viewer
+const redocHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>body { margin: 0; }</style>
+</head>
+<body>
+<redoc spec-url="openapi.json"></redoc>
+<script src="assets/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// rapiDocHTML is the page of RapiDoc viewer
+const rapiDocHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<script type="module" src="assets/rapidoc-min.js"></script>
+</head>
+<body>
+<rapi-doc spec-url="openapi.json" render-style="read"></rapi-doc>
+</body>
+</html>
+`
@@ -0,0 +1,132 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocsHandler(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.AddPath("/books", "Books", "").AddOperation("get").
+		Returns(200, "Books", "Book", []Book{})
+	h, err := DocsHandler(o, DocsOptions{
+		Title:      "Books <API>",
+		BasePath:   "/docs/",
+		ExpandTags: []string{"books"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cached spec doesn't change with document
+	o.AddPath("/authors", "Authors", "")
+
+	testCases := []struct {
+		target      string
+		code        int
+		contentType string
+		contains    []string
+		excludes    []string
+	}{
+		{"/docs", 302, "", nil, nil},
+		{"/docs/", 200, "text/html; charset=utf-8", []string{`<title>Books &lt;API&gt;</title>`, `var expandTags = ["books"];`}, nil},
+		{"/docs/openapi.json", 200, MimeJSON, []string{`"/books":`}, []string{`"/authors"`}},
+		{"/docs/openapi.yaml", 200, MimeYAML, []string{"/books:"}, []string{"/authors:"}},
+		{"/docs/swagger.json", 404, "", nil, nil},
+		{"/openapi.json", 404, "", nil, nil},
+	}
+	for _, tC := range testCases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tC.target, nil))
+		if w.Code != tC.code {
+			t.Fatalf("Expect code %d for %s, got %d", tC.code, tC.target, w.Code)
+		}
+		if tC.contentType != "" && w.Header().Get("Content-Type") != tC.contentType {
+			t.Fatalf("Expect Content-Type %s for %s, got %s", tC.contentType, tC.target, w.Header().Get("Content-Type"))
+		}
+		for _, s := range tC.contains {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Fatalf("Expect %s in %s, got:\n%s", s, tC.target, w.Body.String())
+			}
+		}
+		for _, s := range tC.excludes {
+			if strings.Contains(w.Body.String(), s) {
+				t.Fatalf("Expect no %s in %s", s, tC.target)
+			}
+		}
+	}
+}
+
+func TestDocsHandlerRegenerate(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := DocsHandler(o, DocsOptions{Regenerate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.AddPath("/authors", "Authors", "")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if !strings.Contains(w.Body.String(), `"/authors"`) {
+		t.Fatalf("Expect regenerated spec, got %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), "<title>"+sampleInfo.Title+"</title>") ||
+		!strings.Contains(w.Body.String(), "var expandTags = [];") {
+		t.Fatalf("Expect default title and no expanded tags, got %s", w.Body.String())
+	}
+}
+
+func TestDocsViewer(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundles := map[DocsViewer]string{
+		SwaggerUI: "swagger-ui-bundle.js",
+		Redoc:     "redoc.standalone.js",
+		RapiDoc:   "rapidoc-min.js",
+	}
+	for viewer, bundle := range bundles {
+		if err := ioutil.WriteFile(filepath.Join(dir, bundle), []byte("// "+string(viewer)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		h, err := DocsHandler(o, DocsOptions{BasePath: "/docs", Viewer: viewer, Assets: http.Dir(dir)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/docs/", nil))
+		if !strings.Contains(w.Body.String(), `src="assets/`+bundle+`"`) {
+			t.Fatalf("Expect %s loaded by page, got:\n%s", bundle, w.Body.String())
+		}
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/docs/assets/"+bundle, nil))
+		if w.Code != 200 || w.Body.String() != "// "+string(viewer) {
+			t.Fatalf("Expect %s served, got %d: %s", bundle, w.Code, w.Body.String())
+		}
+	}
+
+	if _, err := DocsHandler(o, DocsOptions{Viewer: "unknown", Assets: http.Dir(dir)}); err == nil {
+		t.Fatal("Expect error for unknown viewer")
+	}
+	if _, err := DocsHandler(o, DocsOptions{Viewer: Redoc}); err == nil || !strings.Contains(err.Error(), ErrNoDocsAssets.Error()) {
+		t.Fatalf("Expect error of no assets, got %v", err)
+	}
+}
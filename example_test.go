@@ -45,6 +45,6 @@ func ExampleNew() {
 	}
 	fmt.Print(string(raw))
 	// Output:
-	// {"openapi":"3.0.0","info":{"title":"testing","version":"v1.0","termsOfService":"abcd","contact":{"name":"Ethan Tang","url":"example.com","email":"someone@example.com"},"license":{"name":"MIT License","url":"http://example.com/mit"}},"paths":{"/books":{"description":"","get":{"summary":"List books","description":"List books","responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/bookArray"},"example":[]}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}},"default":{"description":"internal errors","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"internal_error","message":"an unknown error occurred in our end"}}}}}},"post":{"summary":"Add new book","description":"Add a new book","requestBody":{"description":"JSON of book info","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}},"required":true},"responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}}}},"summary":""},"/books/{id}":{"description":"","get":{"summary":"Get single book","description":"Info of a book","responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}}}},"parameters":[{"name":"id","in":"path","description":"ID of the book","required":true,"schema":{"type":"string"}}],"summary":""}},"components":{"schemas":{"book":{"type":"object","properties":{"author":{"type":"string","maxLength":128,"minLength":1},"date":{"type":"string","format":"date"},"name":{"type":"string","maxLength":128,"minLength":1}},"required":["name","author"]},"bookArray":{"type":"array","items":{"type":"object","properties":{"author":{"type":"string","maxLength":128,"minLength":1},"date":{"type":"string","format":"date"},"name":{"type":"string","maxLength":128,"minLength":1}},"required":["name","author"]}},"replyError":{"type":"object","properties":{"code":{"type":"integer","description":"Error code of current string, read by program"},"message":{"type":"string","description":"Human friendly message that may help with the problem"}}}}}}
+	// {"openapi":"3.0.0","info":{"title":"testing","version":"v1.0","termsOfService":"abcd","contact":{"name":"Ethan Tang","url":"example.com","email":"someone@example.com"},"license":{"name":"MIT License","url":"http://example.com/mit"}},"paths":{"/books":{"summary":"","description":"","get":{"summary":"List books","description":"List books","responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/bookArray"},"example":[]}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}},"default":{"description":"internal errors","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"internal_error","message":"an unknown error occurred in our end"}}}}}},"post":{"summary":"Add new book","description":"Add a new book","requestBody":{"description":"JSON of book info","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}},"required":true},"responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}}}}},"/books/{id}":{"summary":"","description":"","get":{"summary":"Get single book","description":"Info of a book","responses":{"200":{"description":"Book content","content":{"application/json":{"schema":{"$ref":"#/components/schemas/book"},"example":{"name":"","author":"","date":""}}}},"404":{"description":"Book not found","content":{"application/json":{"schema":{"$ref":"#/components/schemas/replyError"},"example":{"code":"book_not_found","message":"The request book is not found"}}}}}},"parameters":[{"name":"id","in":"path","description":"ID of the book","required":true,"schema":{"type":"string"}}]}},"components":{"schemas":{"book":{"type":"object","properties":{"name":{"type":"string","maxLength":128,"minLength":1},"author":{"type":"string","maxLength":128,"minLength":1},"date":{"type":"string","format":"date"}},"required":["name","author","date"]},"bookArray":{"type":"array","items":{"$ref":"#/components/schemas/book"}},"replyError":{"type":"object","properties":{"code":{"type":"integer","description":"Error code of current string, read by program"},"message":{"type":"string","description":"Human friendly message that may help with the problem"}}}}}}
 	//
 }
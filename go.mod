@@ -3,7 +3,6 @@ module github.com/tangyanhan/go-openapi
 go 1.12
 
 require (
-	github.com/ghodss/yaml v1.0.0
 	github.com/go-playground/locales v0.12.1 // indirect
 	github.com/go-playground/universal-translator v0.16.0 // indirect
 	github.com/go-playground/validator v9.29.0+incompatible
@@ -11,4 +10,5 @@ require (
 	github.com/leodido/go-urn v1.1.0 // indirect
 	github.com/stretchr/testify v1.4.0 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
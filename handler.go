@@ -0,0 +1,81 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type pathValuesKey struct{}
+
+// Handle bind handler serving the operation, it's routed by Router.Handler
+func (o *Operation) Handle(h http.Handler) *Operation {
+	o.handler = h
+	return o
+}
+
+// HandleFunc bind handler function serving the operation
+func (o *Operation) HandleFunc(fn func(w http.ResponseWriter, r *http.Request)) *Operation {
+	return o.Handle(http.HandlerFunc(fn))
+}
+
+// PathValues return values of path template variables of request routed by Router.Handler
+func PathValues(r *http.Request) map[string]string {
+	values, _ := r.Context().Value(pathValuesKey{}).(map[string]string)
+	return values
+}
+
+// PathValue return value of path template variable, like id of /books/{id}
+func PathValue(r *http.Request, name string) string {
+	return PathValues(r)[name]
+}
+
+// Handler route requests to handlers of operations in router and its sub routers.
+// Paths are collected when it's created, so add all routes before it.
+// Unknown paths get 404, unknown methods get 405, and operations without handler get 501
+func (r *router) Handler() http.Handler {
+	paths := make(pathMap)
+	r.collectPaths(paths)
+	return &routeHandler{
+		matcher: newPathMatcher(paths),
+	}
+}
+
+func (r *router) collectPaths(paths pathMap) {
+	for _, p := range r.paths {
+		paths[p.path] = p
+	}
+	for _, sub := range r.subRoutes {
+		sub.collectPaths(paths)
+	}
+}
+
+type routeHandler struct {
+	matcher *pathMatcher
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, values := h.matcher.match(r.URL.EscapedPath())
+	if route == nil {
+		http.NotFound(w, r)
+		return
+	}
+	op, ok := route.path.operations[strings.ToLower(r.Method)]
+	if !ok {
+		var allow []string
+		for _, method := range pathMethods {
+			if _, ok := route.path.operations[method]; ok {
+				allow = append(allow, strings.ToUpper(method))
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if op.handler == nil {
+		http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+		return
+	}
+	ctx := context.WithValue(r.Context(), pathValuesKey{}, values)
+	op.handler.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterHandler(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.Route("/{namespace}", func(r Router) {
+		r.WithPathParam("namespace", "Namespace of books")
+		r.Route("/books", func(r Router) {
+			r.GET("", "List books", "").
+				HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, "list %s", PathValue(r, "namespace"))
+				})
+			r.Route("/{id}", func(r Router) {
+				r.WithPathParam("id", "ID of book")
+				r.GET("", "Get book", "").
+					HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+						fmt.Fprintf(w, "get %s %s", PathValue(r, "namespace"), PathValue(r, "id"))
+					})
+				r.PUT("", "Update book", "")
+			})
+			r.GET("/latest", "Latest book", "").
+				HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprintf(w, "latest %s", PathValue(r, "namespace"))
+				})
+		})
+	})
+	h := r.Handler()
+
+	testCases := []struct {
+		method string
+		target string
+		code   int
+		expect string
+	}{
+		{"GET", "/public/books", 200, "list public"},
+		{"GET", "/public/books/", 200, "list public"},
+		{"GET", "/public/books/go%2Flang", 200, "get public go/lang"},
+		{"GET", "/public/books/latest", 200, "latest public"},
+		{"PUT", "/public/books/1", 501, ""},
+		{"DELETE", "/public/books/1", 405, ""},
+		{"GET", "/public/authors", 404, ""},
+	}
+	for _, tC := range testCases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tC.method, tC.target, nil))
+		if w.Code != tC.code {
+			t.Fatalf("Expect code %d for %s %s, got %d", tC.code, tC.method, tC.target, w.Code)
+		}
+		if tC.expect != "" && w.Body.String() != tC.expect {
+			t.Fatalf("Expect %q for %s %s, got %q", tC.expect, tC.method, tC.target, w.Body.String())
+		}
+		if tC.code == 405 && w.Header().Get("Allow") != "GET, PUT" {
+			t.Fatalf("Expect Allow header, got %q", w.Header().Get("Allow"))
+		}
+	}
+
+	if _, ok := o.Paths["/{namespace}/books/{id}"]; !ok {
+		t.Fatal("Expect routed path in document")
+	}
+}
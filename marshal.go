@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// marshalOrdered marshal an object with its keys in given order
+func marshalOrdered(keys []string, value func(key string) interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteRune('{')
+	for i, key := range keys {
+		if i != 0 {
+			b.WriteRune(',')
+		}
+		raw, err := json.Marshal(value(key))
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(strconv.Quote(key))
+		b.WriteRune(':')
+		b.Write(raw)
+	}
+	b.WriteRune('}')
+	return b.Bytes(), nil
+}
+
+// orderedKeys returns keys in given order first, then the rest keys sorted
+func orderedKeys(order []string, exists func(key string) bool, all []string) []string {
+	keys := make([]string, 0, len(all))
+	seen := make(map[string]bool, len(all))
+	for _, key := range order {
+		if !seen[key] && exists(key) {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	rest := make([]string, 0, len(all)-len(keys))
+	for _, key := range all {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// jsonToYAML convert JSON to YAML, keeping keys in the same order
+func jsonToYAML(raw []byte) ([]byte, error) {
+	iter := jsoniter.ParseBytes(json, raw)
+	v := readOrdered(iter)
+	if iter.Error != nil {
+		return nil, iter.Error
+	}
+	return yamlv2.Marshal(v)
+}
+
+// yamlToJSON convert YAML to JSON, keeping keys in the same order
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v orderedYAML
+	if err := yamlv2.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&v)
+}
+
+// orderedYAML is a YAML value that remembers order of mapping keys
+type orderedYAML struct {
+	keys   []interface{}
+	values map[interface{}]*orderedYAML
+	items  []*orderedYAML
+	scalar interface{}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (v *orderedYAML) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	switch raw.(type) {
+	case map[interface{}]interface{}:
+		// MapSlice only keeps order of the first level,
+		// so values are decoded again to keep order of nested mappings
+		var mapping yamlv2.MapSlice
+		if err := unmarshal(&mapping); err != nil {
+			return err
+		}
+		if err := unmarshal(&v.values); err != nil {
+			return err
+		}
+		v.keys = make([]interface{}, len(mapping))
+		for i, item := range mapping {
+			v.keys[i] = item.Key
+		}
+	case []interface{}:
+		v.items = []*orderedYAML{}
+		return unmarshal(&v.items)
+	default:
+		v.scalar = raw
+	}
+	return nil
+}
+
+// MarshalJSON marshal mappings with keys in order
+func (v *orderedYAML) MarshalJSON() ([]byte, error) {
+	switch {
+	case v == nil:
+		return []byte("null"), nil
+	case v.keys != nil:
+		keys := make([]string, len(v.keys))
+		values := make(map[string]*orderedYAML, len(v.keys))
+		for i, key := range v.keys {
+			keys[i] = fmt.Sprint(key)
+			values[keys[i]] = v.values[key]
+		}
+		return marshalOrdered(keys, func(key string) interface{} {
+			return values[key]
+		})
+	case v.items != nil:
+		return json.Marshal(v.items)
+	default:
+		return json.Marshal(v.scalar)
+	}
+}
+
+func readOrdered(iter *jsoniter.Iterator) interface{} {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+		obj := yamlv2.MapSlice{}
+		iter.ReadMapCB(func(iter *jsoniter.Iterator, key string) bool {
+			obj = append(obj, yamlv2.MapItem{
+				Key:   key,
+				Value: readOrdered(iter),
+			})
+			return true
+		})
+		return obj
+	case jsoniter.ArrayValue:
+		arr := []interface{}{}
+		iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			arr = append(arr, readOrdered(iter))
+			return true
+		})
+		return arr
+	case jsoniter.NumberValue:
+		number := iter.ReadNumber().String()
+		if !strings.ContainsAny(number, ".eE") {
+			if i, err := strconv.ParseInt(number, 10, 64); err == nil {
+				return i
+			}
+		}
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			iter.ReportError("readOrdered", fmt.Sprintf("invalid number %s", number))
+		}
+		return f
+	default:
+		return iter.Read()
+	}
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// pathMatcher finds path of document for request url path
+type pathMatcher struct {
+	routes []*pathRoute
+}
+
+type pathRoute struct {
+	template string
+	path     *Path
+	re       *regexp.Regexp
+	names    []string
+	// literal is length of template without variables, longer ones are more specific
+	literal int
+}
+
+func newPathMatcher(paths pathMap) *pathMatcher {
+	m := &pathMatcher{}
+	for template, p := range paths {
+		m.add(template, p)
+	}
+	return m
+}
+
+// add path template like /books/{id}, a template variable matches a single path segment
+func (m *pathMatcher) add(template string, p *Path) {
+	route := &pathRoute{
+		template: template,
+		path:     p,
+	}
+	var b strings.Builder
+	b.WriteRune('^')
+	rest := template
+	for {
+		loc := pathTemplateVar.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:loc[0]]))
+		b.WriteString("([^/]+)")
+		route.literal += loc[0]
+		route.names = append(route.names, rest[loc[2]:loc[3]])
+		rest = rest[loc[1]:]
+	}
+	b.WriteString(regexp.QuoteMeta(rest))
+	b.WriteRune('$')
+	route.literal += len(rest)
+	route.re = regexp.MustCompile(b.String())
+
+	m.routes = append(m.routes, route)
+	sort.SliceStable(m.routes, func(i, j int) bool {
+		a, b := m.routes[i], m.routes[j]
+		if a.literal != b.literal {
+			return a.literal > b.literal
+		}
+		if len(a.names) != len(b.names) {
+			return len(a.names) < len(b.names)
+		}
+		return a.template < b.template
+	})
+}
+
+// match url path with escaped form, returns unescaped values of template variables
+func (m *pathMatcher) match(escapedPath string) (*pathRoute, map[string]string) {
+	if len(escapedPath) > 1 {
+		escapedPath = strings.TrimSuffix(escapedPath, "/")
+	}
+	for _, route := range m.routes {
+		matches := route.re.FindStringSubmatch(escapedPath)
+		if matches == nil {
+			continue
+		}
+		values := make(map[string]string, len(route.names))
+		for i, name := range route.names {
+			v, err := url.PathUnescape(matches[i+1])
+			if err != nil {
+				v = matches[i+1]
+			}
+			values[name] = v
+		}
+		return route, values
+	}
+	return nil, nil
+}
@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a media range of Accept header, like text/* or application/json;q=0.8
+type acceptRange struct {
+	mimeType string
+	q        float64
+	// order in Accept header, earlier ranges are preferred on ties
+	order int
+}
+
+// parseAccept parse media ranges of Accept header, an absent header accepts anything.
+// Invalid ranges are ignored
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{mimeType: "*/*", q: 1}}
+	}
+	var ranges []acceptRange
+	for i, part := range strings.Split(header, ",") {
+		mimeType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{
+			mimeType: mimeType,
+			q:        q,
+			order:    i,
+		})
+	}
+	return ranges
+}
+
+// specificity of media range matching mimeType: 3 for the same type, 2 for type/*, 1 for */* and 0 for no match
+func (a acceptRange) specificity(mimeType string) int {
+	switch {
+	case a.mimeType == mimeType:
+		return 3
+	case a.mimeType == "*/*":
+		return 1
+	case strings.HasSuffix(a.mimeType, "/*") && strings.HasPrefix(mimeType, a.mimeType[:len(a.mimeType)-1]):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// NegotiateContentType pick media type declared by response of code to reply request r, according to its Accept header.
+// The default response is used if code is not declared, and JSON is preferred when more than one is equally acceptable.
+// Undeclared codes and responses without content accept anything with an empty media type.
+// ok is false if none is acceptable
+func (o *Operation) NegotiateContentType(r *http.Request, code int) (mimeType string, ok bool) {
+	declared, err := o.responseMimeTypes(code)
+	if err != nil {
+		return "", false
+	}
+	if len(declared) == 0 {
+		return "", true
+	}
+	ranges := parseAccept(r.Header.Get("Accept"))
+	best, bestSpecificity, bestOrder, bestQ := "", 0, 0, 0.0
+	for _, candidate := range declared {
+		// The most specific range matching the candidate decides its quality
+		var match *acceptRange
+		specificity := 0
+		for i := range ranges {
+			if s := ranges[i].specificity(candidate); s > specificity {
+				match, specificity = &ranges[i], s
+			}
+		}
+		if match == nil || match.q == 0 {
+			continue
+		}
+		if match.q > bestQ || match.q == bestQ && (specificity > bestSpecificity ||
+			specificity == bestSpecificity && match.order < bestOrder) {
+			best, bestSpecificity, bestOrder, bestQ = candidate, specificity, match.order, match.q
+		}
+	}
+	return best, best != ""
+}
+
+// Negotiate is NegotiateContentType that sets Content-Type of w to the picked media type.
+// When none is acceptable, it replies 406 with JSON of RequestError listing media types of the response
+func (o *Operation) Negotiate(w http.ResponseWriter, r *http.Request, code int) (mimeType string, ok bool) {
+	mimeType, ok = o.NegotiateContentType(r, code)
+	if !ok {
+		declared, _ := o.responseMimeTypes(code)
+		writeError(w, http.StatusNotAcceptable, &RequestError{
+			Message: "not acceptable",
+			Violations: []*Violation{{
+				In:      string(HeaderParam),
+				Name:    "Accept",
+				Message: "expect one of " + strings.Join(declared, ", "),
+			}},
+		})
+		return "", false
+	}
+	if mimeType != "" {
+		w.Header().Set("Content-Type", mimeType)
+	}
+	return mimeType, true
+}
+
+// responseMimeTypes return media types of response of code, or the default response. JSON goes first, others are sorted
+func (o *Operation) responseMimeTypes(code int) ([]string, error) {
+	resp, ok := o.Responses[strconv.Itoa(code)]
+	if !ok {
+		if resp, ok = o.Responses["default"]; !ok {
+			return nil, nil
+		}
+	}
+	resp, err := newValueChecker(o.Root()).resolveResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	mimeTypes := make([]string, 0, len(resp.Content))
+	for mimeType := range resp.Content {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Slice(mimeTypes, func(i, j int) bool {
+		if isJSON := isJSONMime(mimeTypes[i]); isJSON != isJSONMime(mimeTypes[j]) {
+			return isJSON
+		}
+		return mimeTypes[i] < mimeTypes[j]
+	})
+	return mimeTypes, nil
+}
@@ -0,0 +1,64 @@
+package openapi
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := o.AddPath("/books", "Books", "").AddOperation("get").
+		ReturnsMedia(200, "Books", "Book", []Book{}, MimeCSV, MimeYAML, MimeJSON).
+		Returns(404, "Not found", "ReplyError", &ReplyError{})
+	content := op.Responses["200"].Content
+	if len(content) != 3 || content[MimeCSV].Schema != content[MimeJSON].Schema || content[MimeCSV].Example != nil {
+		t.Fatalf("expect 3 media types sharing schema, got %v", content)
+	}
+	post := o.AddPath("/authors/{id}/books", "Books of author", "").AddOperation("post").
+		ReadMedia("Book", true, "Book", &Book{}, MimeJSON, MimeYAML).
+		Returns(200, "Book", "Book", &Book{})
+	if body := post.RequestBody.Content; len(body) != 2 || body[MimeYAML].Schema.Ref != "#/components/schemas/Book" {
+		t.Fatalf("expect JSON and YAML body, got %v", body)
+	}
+
+	testCases := []struct {
+		accept string
+		code   int
+		expect string
+	}{
+		{"", 200, MimeJSON},
+		{"*/*", 200, MimeJSON},
+		{"text/*", 200, MimeCSV},
+		{"application/yaml, application/json", 200, MimeYAML},
+		{"application/json;q=0.5, application/yaml", 200, MimeYAML},
+		{"application/*;q=0.9, application/json;q=0", 200, MimeYAML},
+		{"*/*;q=0.1, text/csv", 200, MimeCSV},
+		{"text/csv", 404, ""},
+		{"text/html", 200, ""},
+		{"text/html", 204, ""},
+	}
+	for _, tC := range testCases {
+		req := httptest.NewRequest("GET", "/books", nil)
+		if tC.accept != "" {
+			req.Header.Set("Accept", tC.accept)
+		}
+		mimeType, ok := op.NegotiateContentType(req, tC.code)
+		if mimeType != tC.expect || ok != (tC.expect != "" || tC.code == 204) {
+			t.Fatalf("%s: expect %q, got %q %v", tC.accept, tC.expect, mimeType, ok)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/books", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	if _, ok := op.Negotiate(w, req, 200); ok || w.Code != 406 {
+		t.Fatalf("expect 406, got %d", w.Code)
+	}
+	expect := `{"message":"not acceptable","violations":[{"in":"header","name":"Accept","message":"expect one of application/json, application/yaml, text/csv"}]}`
+	if w.Body.String() != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, w.Body.String())
+	}
+}
@@ -11,8 +11,6 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/ghodss/yaml"
-	"github.com/go-playground/validator"
 	jsoniter "github.com/json-iterator/go"
 )
 
@@ -36,12 +34,20 @@ var (
 	ErrNoRoot           = errors.New("root not initialized with NewRoot")
 	ErrNoOneOf          = errors.New("oneOf has no alternatives")
 	ErrInvalidSchemaDoc = errors.New("invalid SchemaDoc method given")
+	ErrRefCycle         = errors.New("circular $ref")
+	ErrRefNotFound      = errors.New("$ref target not found")
+	ErrRecursiveType    = errors.New("recursive type can only be referenced in document")
+	ErrNoDocsAssets     = errors.New("no assets for docs viewer, the bundle must be supplied")
 )
 
 // Supported mime types when using shortcuts
 const (
-	MimeJSON = "application/json"
-	MimeYAML = "application/yaml"
+	MimeJSON      = "application/json"
+	MimeYAML      = "application/yaml"
+	MimeForm      = "application/x-www-form-urlencoded"
+	MimeMultipart = "multipart/form-data"
+	MimeCSV       = "text/csv"
+	MimeProtobuf  = "application/x-protobuf"
 )
 
 // New create OpenAPI document object and set it as global document root
@@ -49,17 +55,18 @@ func New(version string, info Info) (*OpenAPI, error) {
 	if !strings.HasPrefix(version, "3.") {
 		return nil, fmt.Errorf("only openapi 3.x is supported")
 	}
-	err := validator.New().Struct(info)
+	err := validate.Struct(info)
 	if err != nil {
 		return nil, err
 	}
 	components := &Components{
-		Schemas:       make(schemaMap),
-		Responses:     make(respMap),
-		Parameters:    make(paramMap),
-		Examples:      make(exampleMap),
-		RequestBodies: make(reqBodyMap),
-		Headers:       make(paramMap),
+		Schemas:         make(schemaMap),
+		Responses:       make(respMap),
+		Parameters:      make(paramMap),
+		Examples:        make(exampleMap),
+		RequestBodies:   make(reqBodyMap),
+		Headers:         make(paramMap),
+		SecuritySchemes: make(securitySchemeMap),
 	}
 
 	return &OpenAPI{
@@ -71,9 +78,13 @@ func New(version string, info Info) (*OpenAPI, error) {
 }
 
 // YAML convert document to yaml.
-// We only have json tags in structs, so special lib must be used
+// We only have json tags in structs, so the document is converted from JSON with keys in the same order
 func (o *OpenAPI) YAML() ([]byte, error) {
-	return yaml.Marshal(o)
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(raw)
 }
 
 // JSON marshal document as JSON
@@ -81,18 +92,183 @@ func (o OpenAPI) JSON() ([]byte, error) {
 	return json.Marshal(o)
 }
 
+// Parse read an OpenAPI document in JSON format
+func Parse(data []byte) (*OpenAPI, error) {
+	o, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(o.OpenAPI, "3.") {
+		return nil, fmt.Errorf("only openapi 3.x is supported")
+	}
+	return o, nil
+}
+
+// ParseYAML read an OpenAPI document in YAML format
+func ParseYAML(data []byte) (*OpenAPI, error) {
+	raw, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// parseDocument parse JSON document without checking its version,
+// files referenced by $ref are not always a complete document
+func parseDocument(data []byte) (*OpenAPI, error) {
+	var o OpenAPI
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	o.init()
+	return &o, nil
+}
+
+// init make sure all maps are allocated and set back-pointers to the root,
+// so that a parsed document can be extended as if it was created by New
+func (o *OpenAPI) init() {
+	if o.Paths == nil {
+		o.Paths = make(pathMap)
+	}
+	if o.Components == nil {
+		o.Components = &Components{}
+	}
+	c := o.Components
+	if c.Schemas == nil {
+		c.Schemas = make(schemaMap)
+	}
+	if c.Responses == nil {
+		c.Responses = make(respMap)
+	}
+	if c.Parameters == nil {
+		c.Parameters = make(paramMap)
+	}
+	if c.Examples == nil {
+		c.Examples = make(exampleMap)
+	}
+	if c.RequestBodies == nil {
+		c.RequestBodies = make(reqBodyMap)
+	}
+	if c.Headers == nil {
+		c.Headers = make(paramMap)
+	}
+	if c.SecuritySchemes == nil {
+		c.SecuritySchemes = make(securitySchemeMap)
+	}
+	for key, schema := range c.Schemas {
+		schema.key = key
+		schema.SetRoot(o)
+	}
+	for _, param := range c.Parameters {
+		o.setParamRoot(param)
+	}
+	for _, param := range c.Headers {
+		o.setParamRoot(param)
+	}
+	for _, resp := range c.Responses {
+		o.setResponseRoot(resp)
+	}
+	for _, body := range c.RequestBodies {
+		o.setContentRoot(body.Content)
+	}
+	for path, p := range o.Paths {
+		p.root = o
+		p.path = path
+		for _, param := range p.Parameters {
+			o.setParamRoot(param)
+		}
+		for _, op := range p.operations {
+			for _, param := range op.Parameters {
+				o.setParamRoot(param)
+			}
+			if op.RequestBody != nil {
+				o.setContentRoot(op.RequestBody.Content)
+			}
+			for _, resp := range op.Responses {
+				o.setResponseRoot(resp)
+			}
+		}
+	}
+}
+
+func (o *OpenAPI) setParamRoot(param *Param) {
+	param.root = o
+	if param.Schema != nil {
+		param.Schema.SetRoot(o)
+	}
+	o.setContentRoot(param.Content)
+}
+
+func (o *OpenAPI) setResponseRoot(resp *Response) {
+	for _, header := range resp.Headers {
+		o.setParamRoot(header)
+	}
+	o.setContentRoot(resp.Content)
+}
+
+func (o *OpenAPI) setContentRoot(content mediaTypeMap) {
+	for _, media := range content {
+		if media.Schema != nil {
+			media.Schema.SetRoot(o)
+		}
+		for _, encoding := range media.Encoding {
+			for _, header := range encoding.Headers {
+				o.setParamRoot(header)
+			}
+		}
+	}
+}
+
 // GetSchema return schema ref if exists
 func (o *OpenAPI) GetSchema(key string) *Schema {
 	_, ok := o.Components.Schemas[key]
 	if ok {
-		return &Schema{
-			key: key,
-			Ref: "#/components/schemas/" + key,
-		}
+		return o.schemaRef(key)
 	}
 	return nil
 }
 
+// schemaRef create a ref to component key, which follows the component when it's renamed
+func (o *OpenAPI) schemaRef(key string) *Schema {
+	ref := &Schema{
+		key: key,
+		Ref: schemaRefPrefix + key,
+	}
+	o.referSchema(key, ref)
+	return ref
+}
+
+// referSchema record that s refers to component key by Ref or discriminator mapping
+func (o *OpenAPI) referSchema(key string, s *Schema) {
+	if o.schemaRefs == nil {
+		o.schemaRefs = make(map[string][]*Schema)
+	}
+	o.schemaRefs[key] = append(o.schemaRefs[key], s)
+}
+
+// renameSchema move component of struct type tv from key to newKey, along with schemas referring to it
+func (o *OpenAPI) renameSchema(tv reflect.Type, key, newKey string) {
+	o.Components.Schemas[newKey] = o.Components.Schemas[key]
+	delete(o.Components.Schemas, key)
+	o.schemaKeys[tv] = newKey
+	delete(o.derivedKeys, tv)
+	ref, newRef := schemaRefPrefix+key, schemaRefPrefix+newKey
+	for _, s := range o.schemaRefs[key] {
+		if s.Ref == ref {
+			s.key, s.Ref = newKey, newRef
+		}
+		if s.Discriminator != nil {
+			for value, mapped := range s.Discriminator.Mapping {
+				if mapped == ref {
+					s.Discriminator.Mapping[value] = newRef
+				}
+			}
+		}
+	}
+	o.schemaRefs[newKey] = append(o.schemaRefs[newKey], o.schemaRefs[key]...)
+	delete(o.schemaRefs, key)
+}
+
 // MustGetSchema create schema for struct when necessary and always return a ref format
 func (o *OpenAPI) MustGetSchema(key string, v interface{}) *Schema {
 	if v == nil {
@@ -100,29 +276,80 @@ func (o *OpenAPI) MustGetSchema(key string, v interface{}) *Schema {
 			root: o,
 		}
 	}
-	schema := o.GetSchema(key)
-	if schema == nil {
-		var err error
-		schema, err = Interface(v)
-		if err != nil {
-			panic(err)
-		}
-		if key == "" {
-			key = genInterfaceKey(v)
+	schema, err := o.getSchema(key, v)
+	if err != nil {
+		o.fail(&BuildError{Err: err})
+		return &Schema{
+			root: o,
 		}
-		schema = o.AddSchema(key, schema)
 	}
 	return schema
 }
 
+// getSchema is MustGetSchema that returns error, v must not be nil
+func (o *OpenAPI) getSchema(key string, v interface{}) (*Schema, error) {
+	if schema := o.GetSchema(key); schema != nil {
+		return schema, nil
+	}
+	derived := key == ""
+	if derived {
+		key = genInterfaceKey(v)
+	}
+	// Type already added, e.g. as a nested field, is not added again. A given key is still honoured:
+	// a component keyed by type name is renamed to it, otherwise key refers to the existing component
+	tv := namedStruct(reflect.TypeOf(v))
+	if registered, ok := o.schemaKeys[tv]; ok && o.GetSchema(registered) != nil {
+		switch {
+		case derived:
+			return o.GetSchema(registered), nil
+		case o.derivedKeys[tv]:
+			o.renameSchema(tv, registered, key)
+			return o.GetSchema(key), nil
+		default:
+			return o.AddSchema(key, o.GetSchema(registered)), nil
+		}
+	}
+	// Nested and recursive values of the same type refer to key
+	if tv != nil {
+		o.reserveSchema(tv, key, derived)
+	}
+	schema, err := (&schemaParser{root: o}).schema(v)
+	if err != nil {
+		if tv != nil {
+			o.releaseSchema(tv, key)
+		}
+		return nil, err
+	}
+	return o.AddSchema(key, schema), nil
+}
+
+// reserveSchema add a placeholder schema for struct type, before its fields are parsed.
+// derived tells the key is derived from the type instead of given
+func (o *OpenAPI) reserveSchema(tv reflect.Type, key string, derived bool) {
+	if o.schemaKeys == nil {
+		o.schemaKeys = make(map[reflect.Type]string)
+		o.derivedKeys = make(map[reflect.Type]bool)
+	}
+	o.schemaKeys[tv] = key
+	if derived {
+		o.derivedKeys[tv] = true
+	}
+	o.Components.Schemas[key] = &Schema{
+		root: o,
+	}
+}
+
+func (o *OpenAPI) releaseSchema(tv reflect.Type, key string) {
+	delete(o.schemaKeys, tv)
+	delete(o.derivedKeys, tv)
+	delete(o.Components.Schemas, key)
+}
+
 // AddSchema add schema to global components, and return a ref
 func (o *OpenAPI) AddSchema(key string, schema *Schema) *Schema {
 	schema.root = o
 	o.Components.Schemas[key] = schema
-	return &Schema{
-		key: key,
-		Ref: "#/components/schemas/" + key,
-	}
+	return o.schemaRef(key)
 }
 
 // AddParam add param definition to global components
@@ -136,7 +363,12 @@ func (o *OpenAPI) AddParam(key string, param *Param) string {
 func (o *OpenAPI) GetParam(key string) *Param {
 	param, ok := o.Components.Parameters[key]
 	if !ok {
-		panic("failed to find param with key:" + key)
+		o.fail(&BuildError{Err: errors.New("failed to find param with key:" + key)})
+		// Keep building with a dangling ref, which is also reported by Validate
+		return &Param{
+			root: o,
+			Ref:  "#/components/parameters/" + key,
+		}
 	}
 	return param
 }
@@ -153,15 +385,21 @@ func (o *OpenAPI) AddHeader(key string, param *Param) string {
 func (o *OpenAPI) GetHeader(key string) *Param {
 	param, ok := o.Components.Headers[key]
 	if !ok {
-		panic("failed to find param with key:" + key)
+		o.fail(&BuildError{Err: errors.New("failed to find header with key:" + key)})
+		return &Param{
+			root: o,
+			Ref:  "#/components/headers/" + key,
+		}
 	}
 	return param
 }
 
-// AddPath to OpenAPI paths section
+// AddPath to OpenAPI paths section.
+// With CollectErrors, the existing path is returned if path already exists
 func (o *OpenAPI) AddPath(path, summary, description string) *Path {
-	if _, exists := o.Paths[path]; exists {
-		panic("path already exists:" + path)
+	if p, exists := o.Paths[path]; exists {
+		o.fail(&BuildError{Path: path, Err: errors.New("path already exists:" + path)})
+		return p
 	}
 	p := &Path{
 		root:        o,
@@ -174,21 +412,33 @@ func (o *OpenAPI) AddPath(path, summary, description string) *Path {
 	return p
 }
 
-// AddOperation add operation to path
+// AddOperation add operation to path.
+// With CollectErrors, a detached operation is returned if method already exists,
+// so that following calls on it won't change the document
 func (p *Path) AddOperation(method string) *Operation {
 	method = strings.ToLower(method)
-	if _, exists := p.operations[method]; exists {
-		panic("operation for path " + p.path + " already exists method " + method)
-	}
 	op := &Operation{
 		method:    method,
 		path:      p,
 		Responses: make(Responses),
 	}
+	if _, exists := p.operations[method]; exists {
+		p.Root().fail(&BuildError{
+			Method: method,
+			Path:   p.path,
+			Err:    errors.New("operation for path " + p.path + " already exists method " + method),
+		})
+		return op
+	}
 	p.operations[method] = op
 	return op
 }
 
+// Operation return operation of method, nil if not exists
+func (p *Path) Operation(method string) *Operation {
+	return p.operations[strings.ToLower(method)]
+}
+
 // NewPathParam create new path param
 func NewPathParam(name, description string) *Param {
 	return &Param{
@@ -200,16 +450,12 @@ func NewPathParam(name, description string) *Param {
 
 // NewQueryParam create new query param
 func NewQueryParam(name, description string, example interface{}) *Param {
-	tv := reflect.TypeOf(example)
-	typ, _ := kindToType(tv.Kind())
 	return &Param{
 		In:          QueryParam,
 		Name:        name,
 		Description: description,
 		Example:     example,
-		Schema: &Schema{
-			Type: typ,
-		},
+		Schema:      querySchema(reflect.TypeOf(example)),
 	}
 }
 
@@ -233,14 +479,59 @@ func (p *Param) SetDeprecated() *Param {
 
 // WithStruct add struct schema for param
 func (p *Param) WithStruct(v interface{}) *Param {
-	schema, err := Interface(v)
+	schema, err := (&schemaParser{root: p.root}).schema(v)
 	if err != nil {
-		panic(err)
+		p.fail(err)
+		return p
 	}
 	p.Schema = schema
 	return p
 }
 
+// WithStyle set how values of param are serialized, explode makes items of arrays and objects separate values.
+// The style must be allowed for location of param
+func (p *Param) WithStyle(style ParamStyle, explode bool) *Param {
+	if !style.IsValidFor(p.In) {
+		p.fail(fmt.Errorf("style %s is not allowed for %s param %s", style, p.In, p.Name))
+		return p
+	}
+	p.Style = style
+	p.Explode = &explode
+	return p
+}
+
+// SetAllowReserved allow reserved characters like :/?#[]@!$&'()*+,;= in values of query param without encoding
+func (p *Param) SetAllowReserved() *Param {
+	if p.In != QueryParam {
+		p.fail(fmt.Errorf("allowReserved is only for query params, got %s param %s", p.In, p.Name))
+		return p
+	}
+	p.AllowReserved = true
+	return p
+}
+
+// WithContent describe param with schema of v in media type, instead of schema. For example, JSON encoded query params
+func (p *Param) WithContent(mimeType string, v interface{}) *Param {
+	schema, err := (&schemaParser{root: p.root}).schema(v)
+	if err != nil {
+		p.fail(err)
+		return p
+	}
+	p.Schema = nil
+	p.Content = mediaTypeMap{
+		mimeType: &MediaType{Schema: schema},
+	}
+	return p
+}
+
+// fail panics with err, or reports it to the document param belongs to
+func (p *Param) fail(err error) {
+	if p.root == nil {
+		panic(err)
+	}
+	p.root.fail(&BuildError{Err: err})
+}
+
 // WithSchema add schema
 func (p *Param) WithSchema(s *Schema) *Param {
 	p.Schema = s
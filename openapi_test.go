@@ -1,6 +1,10 @@
 package openapi
 
 import (
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -102,3 +106,277 @@ func TestParamSchema(t *testing.T) {
 	}
 	t.Log(string(raw))
 }
+
+func TestParse(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.Route("/books/{id}", func(r Router) {
+		r.WithPathParam("id", "ID of the book")
+		r.GET("", "Get single book", "Info of a book").
+			Returns(200, "Book content", "book", &Book{})
+	})
+	raw, err := o.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := parsed.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Examples are parsed as generic values, so compare documents semantically
+	var expect, got interface{}
+	if err := json.Unmarshal(raw, &expect); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(again, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expect, got) {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", raw, again)
+	}
+	path, ok := parsed.Paths["/books/{id}"]
+	if !ok || path.Root() != parsed {
+		t.Fatal("path not parsed with root")
+	}
+	op, ok := path.operations["get"]
+	if !ok || op.Root() != parsed {
+		t.Fatal("operation get not parsed")
+	}
+	ref := op.Responses["200"].Content[MimeJSON].Schema
+	if ref.Ref != "#/components/schemas/book" || ref.key != "book" {
+		t.Fatalf("unexpected ref %s with key %s", ref.Ref, ref.key)
+	}
+
+	// A parsed document keeps growing with router
+	NewRouter(parsed).POST("/books/{id}", "Update book", "Update a book").
+		ReadJSON("JSON of book info", true, "book", &Book{})
+	if _, ok := path.operations["post"]; !ok {
+		t.Fatal("failed to add operation to parsed path")
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	doc := `
+openapi: 3.0.0
+info:
+  title: testing
+  version: v1.0
+paths:
+  /books:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/book'
+components:
+  schemas:
+    book:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+    flag:
+      type: boolean
+      required: true
+`
+	o, err := ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := o.Components.Schemas["book"]
+	if book.Required == nil || len(book.Required.Properties) != 1 || book.Required.Properties[0] != "name" {
+		t.Fatalf("unexpected required %+v", book.Required)
+	}
+	flag := o.Components.Schemas["flag"]
+	if flag.Required == nil || !flag.Required.Required {
+		t.Fatalf("unexpected required %+v", flag.Required)
+	}
+	if o.GetSchema("book") == nil {
+		t.Fatal("schema book not found")
+	}
+	if _, ok := o.Paths["/books"].operations["get"]; !ok {
+		t.Fatal("operation get not parsed")
+	}
+	if _, err := ParseYAML([]byte("openapi: 2.0\n")); err == nil {
+		t.Fatal("expect error for openapi 2.0")
+	}
+}
+
+func TestYAMLOrder(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.Route("/books", func(r Router) {
+		r.POST("", "Add book", "Add a new book").Returns(200, "Book content", "book", &Book{})
+		r.GET("", "List books", "List books").Returns(200, "Book content", "bookArray", []*Book{})
+	})
+	raw, err := o.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(raw)
+	expectOrder := func(doc string, keys ...string) {
+		rest := doc
+		for _, key := range keys {
+			i := strings.Index(rest, key)
+			if i < 0 {
+				t.Fatalf("expect keys in order %v in:\n%s", keys, doc)
+			}
+			rest = rest[i+len(key):]
+		}
+	}
+	expectOrder(doc, "openapi:", "info:", "paths:", "    get:", "    post:", "components:")
+	expectOrder(doc, "name:", "author:", "date:")
+
+	// Order of properties is kept after parsing, and output is stable
+	parsed, err := ParseYAML(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := parsed.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectOrder(string(again), "components:", "name:", "author:", "date:")
+	parsed, err = ParseYAML(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := parsed.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(last) {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", again, last)
+	}
+}
+
+type pageQuery struct {
+	Page int `query:"page" validate:"min=1" default:"1"`
+	Size int `query:"size" validate:"min=1,max=100"`
+}
+
+type bookFilter struct {
+	pageQuery
+	Author string   `query:"author" description:"Name of author"`
+	Tags   []string `query:"tag" validate:"max=5"`
+	Sort   string   `json:"sort" validate:"oneof=name date"`
+	Old    bool     `query:"old" deprecated:"true"`
+	Ignore string   `query:"-"`
+}
+
+type bookHeaders struct {
+	RequestID string `header:"X-Request-ID" validate:"required,uuid"`
+}
+
+type bookCookies struct {
+	Session string `cookie:"session" validate:"required"`
+}
+
+func TestStructParams(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := o.AddPath("/books", "Books", "").AddOperation("get").
+		WithQueryStruct(&bookFilter{Author: "rob"}).
+		WithHeaderStruct(bookHeaders{}).
+		WithCookieStruct(bookCookies{}).
+		Returns(200, "Books", "Book", []Book{})
+	raw, err := json.Marshal(op.Parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `[{"name":"page","in":"query","required":false,"schema":{"type":"integer","format":"int64","default":1,"minimum":1}},` +
+		`{"name":"size","in":"query","required":false,"schema":{"type":"integer","format":"int64","maximum":100,"minimum":1}},` +
+		`{"name":"author","in":"query","description":"Name of author","required":false,"schema":{"type":"string"},"example":"rob"},` +
+		`{"name":"tag","in":"query","required":false,"schema":{"type":"array","items":{"type":"string"},"maxItems":5}},` +
+		`{"name":"sort","in":"query","required":false,"schema":{"type":"string","enum":["name","date"]}},` +
+		`{"name":"old","in":"query","required":false,"deprecated":true,"schema":{"type":"boolean"}},` +
+		`{"name":"X-Request-ID","in":"header","required":true,"schema":{"type":"string","format":"uuid"}},` +
+		`{"name":"session","in":"cookie","required":true,"schema":{"type":"string"}}]`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	o.CollectErrors()
+	o.AddPath("/authors", "Authors", "").AddOperation("get").WithQueryStruct("not a struct")
+	if err := o.Err(); err == nil || !strings.Contains(err.Error(), "expect struct for query params") {
+		t.Fatalf("Expect error for non-struct, got %v", err)
+	}
+}
+
+type bookUpload struct {
+	Book  Book                  `json:"book"`
+	Cover *multipart.FileHeader `json:"cover" contentType:"image/png"`
+	Pages []byte                `json:"pages"`
+	Notes io.Reader             `json:"notes,omitempty"`
+	Tags  []string              `json:"tags,omitempty"`
+}
+
+func TestReadBodies(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := o.AddPath("/books/upload", "Upload book", "").AddOperation("post").
+		ReadMultipart("Book with files", true, "bookUpload", &bookUpload{}).
+		Returns(200, "Book", "Book", &Book{})
+	// JSON of the same type still encodes []byte in base64
+	o.AddPath("/books/uploads", "Uploads", "").AddOperation("get").
+		Returns(200, "Upload", "bookUpload", &bookUpload{})
+	raw, err := json.Marshal(upload.RequestBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"description":"Book with files","content":{"multipart/form-data":{"schema":{"$ref":"#/components/schemas/bookUploadMultipart"},` +
+		`"encoding":{"book":{"contentType":"application/json"},"cover":{"contentType":"image/png"},` +
+		`"notes":{"contentType":"application/octet-stream"},"pages":{"contentType":"application/octet-stream"},` +
+		`"tags":{"contentType":"text/plain"}}}},"required":true}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+	for key, pages := range map[string]string{"bookUpload": "byte", "bookUploadMultipart": "binary"} {
+		raw, err = json.Marshal(o.Components.Schemas[key])
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect = `{"type":"object","properties":{"book":{"$ref":"#/components/schemas/Book"},` +
+			`"cover":{"type":"string","format":"binary","nullable":true},"pages":{"type":"string","format":"` + pages + `","nullable":true},` +
+			`"notes":{"type":"string","format":"binary"},"tags":{"type":"array","items":{"type":"string"}}},"required":["book","cover","pages"]}`
+		if string(raw) != expect {
+			t.Fatalf("Expect %s:\n%s\nGot:\n%s", key, expect, string(raw))
+		}
+	}
+
+	form := o.AddPath("/books", "Books", "").AddOperation("post").
+		ReadForm("Book", true, "book", &Book{}).
+		Returns(200, "Book", "Book", &Book{})
+	if media := form.RequestBody.Content[MimeForm]; media == nil || media.Schema.Ref != "#/components/schemas/book" {
+		t.Fatalf("Expect form body of Book, got %v", form.RequestBody.Content)
+	}
+	upload.RequestBody.Content[MimeMultipart].Encoding["cover"].
+		WithHeader("X-Checksum", &Param{Schema: &Schema{Type: "string"}})
+	upload.RequestBody.Content[MimeMultipart].Encoding["missing"] = &Encoding{ContentType: "text/plain"}
+	errs := o.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "encoding/missing: no property missing in schema") {
+		t.Fatalf("Expect error of missing property, got %v", errs)
+	}
+}
@@ -1,6 +1,9 @@
 package openapi
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
 )
@@ -9,6 +12,7 @@ import (
 type Operation struct {
 	method      string
 	path        *Path
+	handler     http.Handler
 	Tags        []string     `json:"tags,omitempty"`
 	Summary     string       `json:"summary,omitempty"`
 	Description string       `json:"description,omitempty"`
@@ -17,6 +21,8 @@ type Operation struct {
 	RequestBody *RequestBody `json:"requestBody,omitempty"`
 	Responses   Responses    `json:"responses" validate:"required"`
 	Deprecated  bool         `json:"deprecated,omitempty"`
+	// Security overrides security of document when not nil, an empty list removes security
+	Security *[]SecurityRequirement `json:"security,omitempty"`
 }
 
 // Metadata add metadata to operation
@@ -43,12 +49,22 @@ func (o *Operation) Root() *OpenAPI {
 
 // Returns with code
 func (o *Operation) Returns(code int, description string, key string, v interface{}) *Operation {
+	return o.ReturnsMedia(code, description, key, v, MimeJSON)
+}
+
+// ReturnsMedia return response of code in each of media types, like MimeJSON, MimeYAML and MimeCSV of the same data.
+// They share schema of v, which is added to components with key
+func (o *Operation) ReturnsMedia(code int, description string, key string, v interface{}, mimeTypes ...string) *Operation {
 	strCode := strconv.Itoa(code)
 	if _, exists := o.Responses[strCode]; exists {
-		panic("operation " + o.OperationID + " already returns code " + strCode)
+		o.fail(strCode, errors.New("response already exists"))
+		return o
+	}
+	if len(mimeTypes) == 0 {
+		o.fail(strCode, errors.New("no media type to return"))
+		return o
 	}
-	r := o.newResponse(description, key, v)
-	o.Responses[strCode] = r
+	o.Responses[strCode] = o.newResponse(strCode, description, key, v, mimeTypes...)
 	return o
 }
 
@@ -57,7 +73,8 @@ func (o *Operation) ReturnsNonJSON(code int, description string,
 	mimeType string, headers map[string]*Param, schema *Schema, example interface{}) *Operation {
 	strCode := strconv.Itoa(code)
 	if _, exists := o.Responses[strCode]; exists {
-		panic("operation " + o.OperationID + " already returns code " + strCode)
+		o.fail(strCode, errors.New("response already exists"))
+		return o
 	}
 	o.Responses[strCode] = &Response{
 		Description: description,
@@ -75,38 +92,157 @@ func (o *Operation) ReturnsNonJSON(code int, description string,
 // ReturnDefault add default response.
 // A default response is the response to be used when none of defined codes match the situation.
 func (o *Operation) ReturnDefault(description string, key string, v interface{}) *Operation {
-	o.Responses["default"] = o.newResponse(description, key, v)
+	o.Responses["default"] = o.newResponse("default", description, key, v, MimeJSON)
 	return o
 }
 
-func (o *Operation) newResponse(description string, key string, v interface{}) *Response {
-	schema := o.Root().MustGetSchema(key, v)
+func (o *Operation) newResponse(code, description string, key string, v interface{}, mimeTypes ...string) *Response {
 	return &Response{
 		Description: description,
 		Headers:     make(paramMap),
-		Content: mediaTypeMap{
-			MimeJSON: &MediaType{
-				Schema:  schema,
-				Example: v,
-			},
-		},
+		Content:     newContent(o.mustGetSchema(code, key, v), v, mimeTypes),
 	}
 }
 
+// newContent create media types sharing schema. The example is a Go value, so it's only set for JSON and YAML
+func newContent(schema *Schema, example interface{}, mimeTypes []string) mediaTypeMap {
+	content := make(mediaTypeMap, len(mimeTypes))
+	for _, mimeType := range mimeTypes {
+		media := &MediaType{
+			Schema: schema,
+		}
+		if isJSONMime(mimeType) || mimeType == MimeYAML {
+			media.Example = example
+		}
+		content[mimeType] = media
+	}
+	return content
+}
+
 // ReadJSON read object json from request body
 func (o *Operation) ReadJSON(description string, required bool, key string, v interface{}) *Operation {
-	schema := o.Root().MustGetSchema(key, v)
+	o.readBody(description, required, key, v, MimeJSON)
+	return o
+}
+
+// ReadMedia read request body in any of media types, which share schema of v added to components with key
+func (o *Operation) ReadMedia(description string, required bool, key string, v interface{}, mimeTypes ...string) *Operation {
+	if len(mimeTypes) == 0 {
+		o.fail("", errors.New("no media type to read"))
+		return o
+	}
+	o.readBody(description, required, key, v, mimeTypes...)
+	return o
+}
+
+// ReadForm read application/x-www-form-urlencoded body, properties are named as in JSON
+func (o *Operation) ReadForm(description string, required bool, key string, v interface{}) *Operation {
+	o.readBody(description, required, key, v, MimeForm)
+	return o
+}
+
+// ReadMultipart read multipart/form-data body of struct v, each field is a part.
+// []byte, *multipart.FileHeader and io.Reader fields are files, described as binary strings.
+// Each part gets an encoding with its content type, which can be set with contentType tag like contentType:"image/png"
+func (o *Operation) ReadMultipart(description string, required bool, key string, v interface{}) *Operation {
+	tv := reflect.TypeOf(v)
+	for tv != nil && tv.Kind() == reflect.Ptr {
+		tv = tv.Elem()
+	}
+	if tv == nil || tv.Kind() != reflect.Struct {
+		o.fail("", fmt.Errorf("expect struct for multipart body, got %v", tv))
+		return o
+	}
+	media := o.readBody(description, required, key, v, MimeMultipart)[MimeMultipart]
+	schema := o.Root().Components.Schemas[media.Schema.key]
+	if schema == nil {
+		return o
+	}
+	media.Encoding = make(map[string]*Encoding)
+	binary := make(map[string]*Schema)
+	for _, f := range jsonFields(tv) {
+		prop := schema.Properties[f.name]
+		if prop == nil {
+			continue
+		}
+		// []byte is uploaded as is, instead of base64
+		if ft := f.Type; ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Uint8 && prop.Format == "byte" {
+			file := *prop
+			file.Format = "binary"
+			prop = &file
+			binary[f.name] = prop
+		}
+		contentType := f.Tag.Get("contentType")
+		if contentType == "" {
+			contentType = partContentType(prop)
+		}
+		media.Encoding[f.name] = &Encoding{
+			ContentType: contentType,
+		}
+	}
+	if len(binary) != 0 {
+		media.Schema = o.Root().multipartSchema(media.Schema.key, schema, binary)
+	}
+	return o
+}
+
+// multipartSchema add a copy of component schema with file properties replaced, under its own key,
+// since the component may be referred by JSON bodies and responses too
+func (o *OpenAPI) multipartSchema(key string, schema *Schema, files map[string]*Schema) *Schema {
+	key += "Multipart"
+	if ref := o.GetSchema(key); ref != nil {
+		return ref
+	}
+	multipart := *schema
+	multipart.Properties = make(map[string]*Schema, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		multipart.Properties[name] = prop
+	}
+	for name, prop := range files {
+		multipart.Properties[name] = prop
+	}
+	return o.AddSchema(key, &multipart)
+}
+
+// readBody set request body of media types with schema of v, which is added to components with key
+func (o *Operation) readBody(description string, required bool, key string, v interface{}, mimeTypes ...string) mediaTypeMap {
 	o.RequestBody = &RequestBody{
 		Description: description,
 		Required:    required,
-		Content: mediaTypeMap{
-			MimeJSON: &MediaType{
-				Schema:  schema,
-				Example: v,
-			},
-		},
+		Content:     newContent(o.mustGetSchema("", key, v), v, mimeTypes),
 	}
-	return o
+	return o.RequestBody.Content
+}
+
+// partContentType return default content type of a multipart part with schema s
+func partContentType(s *Schema) string {
+	if s.Type == "array" && s.Items != nil {
+		return partContentType(s.Items)
+	}
+	switch {
+	case s.Type == "string" && s.Format == "binary":
+		return "application/octet-stream"
+	case s.Type == "string" || s.Type == "integer" || s.Type == "number" || s.Type == "boolean":
+		return "text/plain"
+	default:
+		return MimeJSON
+	}
+}
+
+// mustGetSchema is MustGetSchema that records failures with context of operation
+func (o *Operation) mustGetSchema(code, key string, v interface{}) *Schema {
+	root := o.Root()
+	if v == nil {
+		return root.MustGetSchema(key, v)
+	}
+	schema, err := root.getSchema(key, v)
+	if err != nil {
+		o.fail(code, err)
+		return &Schema{
+			root: root,
+		}
+	}
+	return schema
 }
 
 // Read read raw body of any kind
@@ -125,14 +261,15 @@ func (o *Operation) Read(description string, required bool, mimeType string, exa
 
 // AddParam with param in operation
 func (o *Operation) AddParam(in ParamType, name, description string) *Param {
-	if !in.IsValid() {
-		panic("invalid param in " + in)
-	}
 	param := &Param{
 		In:          in,
 		Name:        name,
 		Description: description,
 	}
+	if !in.IsValid() {
+		o.fail("", errors.New("invalid param in "+string(in)))
+		return param
+	}
 	// A path param is always required
 	if in == PathParam {
 		param.Required = true
@@ -146,8 +283,9 @@ func (o *Operation) AddParam(in ParamType, name, description string) *Param {
 
 // WithParam add param to operation
 func (o *Operation) WithParam(param *Param) *Operation {
-	if !param.In.IsValid() {
-		panic("invalid param in " + param.In)
+	if param.Ref == "" && !param.In.IsValid() {
+		o.fail("", errors.New("invalid param in "+string(param.In)))
+		return o
 	}
 	o.Parameters = append(o.Parameters, param)
 	return o
@@ -166,21 +304,75 @@ func (o *Operation) WithPathParam(name, description string) *Operation {
 	})
 }
 
+// SyncPathParams add string path params for variables in path template that are not declared yet
+func (o *Operation) SyncPathParams() *Operation {
+	declared := make(map[string]bool)
+	for _, param := range append(append([]*Param{}, o.path.Parameters...), o.Parameters...) {
+		if param.In == PathParam {
+			declared[param.Name] = true
+		}
+	}
+	for _, match := range pathTemplateVar.FindAllStringSubmatch(o.path.path, -1) {
+		if name := match[1]; !declared[name] {
+			declared[name] = true
+			o.WithPathParam(name, "")
+		}
+	}
+	return o
+}
+
 // WithQueryParam add query param. Complex types of query param is not supported here(e.g., a struct or slice)
 func (o *Operation) WithQueryParam(name, description string, example interface{}) *Operation {
-	tv := reflect.TypeOf(example)
-	typ, _ := kindToType(tv.Kind())
 	return o.WithParam(&Param{
 		In:          QueryParam,
 		Name:        name,
 		Description: description,
 		Example:     example,
-		Schema: &Schema{
-			Type: typ,
-		},
+		Schema:      querySchema(reflect.TypeOf(example)),
 	})
 }
 
+// WithQueryStruct add a query param for each field of struct v, named by query tags like query:"name".
+// Other tags of fields like validate and description work as they do for schemas
+func (o *Operation) WithQueryStruct(v interface{}) *Operation {
+	return o.withStructParams(QueryParam, v)
+}
+
+// WithHeaderStruct add a header param for each field of struct v, named by header tags like header:"X-Request-ID"
+func (o *Operation) WithHeaderStruct(v interface{}) *Operation {
+	return o.withStructParams(HeaderParam, v)
+}
+
+// WithCookieStruct add a cookie param for each field of struct v, named by cookie tags like cookie:"session"
+func (o *Operation) WithCookieStruct(v interface{}) *Operation {
+	return o.withStructParams(CookieParam, v)
+}
+
+func (o *Operation) withStructParams(in ParamType, v interface{}) *Operation {
+	params, err := (&schemaParser{root: o.Root()}).params(in, v)
+	if err != nil {
+		o.fail("", err)
+		return o
+	}
+	for _, param := range params {
+		o.WithParam(param)
+	}
+	return o
+}
+
+// WithSecurity override security requirements of document for this operation.
+// Any one of the requirements is enough to access the operation
+func (o *Operation) WithSecurity(reqs ...SecurityRequirement) *Operation {
+	security := append([]SecurityRequirement{}, reqs...)
+	o.Security = &security
+	return o
+}
+
+// NoSecurity make operation accessible without security
+func (o *Operation) NoSecurity() *Operation {
+	return o.WithSecurity()
+}
+
 // WithTags add tags
 func (o *Operation) WithTags(tags ...string) *Operation {
 	o.Tags = append(o.Tags, tags...)
@@ -0,0 +1,273 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Violation is a single problem found in a request or response
+type Violation struct {
+	// In is where the problem is: path, query, header, cookie or body
+	In string `json:"in"`
+	// Name of param, or JSON pointer in body
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+// RequestError lists all violations of a request, it's written as response body of bad requests
+type RequestError struct {
+	Message    string       `json:"message"`
+	Violations []*Violation `json:"violations"`
+}
+
+func (e *RequestError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.In + " " + v.Name + ": " + v.Message
+	}
+	return e.Message + ": " + strings.Join(msgs, "; ")
+}
+
+// RequestValidatorOptions options for request validator
+type RequestValidatorOptions struct {
+	// RejectUnknown respond 404/405 for requests not in document, instead of passing them on
+	RejectUnknown bool
+	// ErrorHandler write response for invalid requests, JSON of RequestError with code 400 by default
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err *RequestError)
+}
+
+// RequestValidator create middleware that validates params and body of requests against document.
+// Paths of document are collected when it's created, so add all routes before it
+func RequestValidator(o *OpenAPI, opts RequestValidatorOptions) func(next http.Handler) http.Handler {
+	v := newRequestValidator(o)
+	matcher := newPathMatcher(o.Paths)
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = writeRequestError
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathValues := matcher.match(r.URL.EscapedPath())
+			if route == nil {
+				if opts.RejectUnknown {
+					http.NotFound(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			op, ok := route.path.operations[strings.ToLower(r.Method)]
+			if !ok {
+				if opts.RejectUnknown {
+					http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := v.validate(r, op, pathValues); err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeRequestError(w http.ResponseWriter, r *http.Request, err *RequestError) {
+	writeError(w, http.StatusBadRequest, err)
+}
+
+// writeError write JSON of err with status code
+func writeError(w http.ResponseWriter, code int, err interface{}) {
+	raw, _ := json.Marshal(err)
+	w.Header().Set("Content-Type", MimeJSON)
+	w.WriteHeader(code)
+	w.Write(raw)
+}
+
+// ValidateRequest validates request against operation, path values are values of path template variables.
+// The request body is read and replaced, so it can still be read afterwards
+func (o *Operation) ValidateRequest(r *http.Request, pathValues map[string]string) error {
+	if err := newRequestValidator(o.Root()).validate(r, o, pathValues); err != nil {
+		return err
+	}
+	return nil
+}
+
+type requestValidator struct {
+	checker *valueChecker
+}
+
+func newRequestValidator(o *OpenAPI) *requestValidator {
+	return &requestValidator{
+		checker: newValueChecker(o),
+	}
+}
+
+func (v *requestValidator) validate(r *http.Request, op *Operation, pathValues map[string]string) *RequestError {
+	var violations []*Violation
+	add := func(in, name, message string) {
+		violations = append(violations, &Violation{
+			In:      in,
+			Name:    name,
+			Message: message,
+		})
+	}
+
+	query := r.URL.Query()
+	params := append(append([]*Param{}, op.path.Parameters...), op.Parameters...)
+	for _, param := range params {
+		param, err := v.checker.resolveParam(param)
+		if err != nil {
+			add("", "", err.Error())
+			continue
+		}
+		in := string(param.In)
+		value, ok, err := v.checker.paramValue(r, query, pathValues, param)
+		if err != nil {
+			add(in, param.Name, err.Error())
+			continue
+		}
+		if !ok {
+			if param.Required {
+				add(in, param.Name, "required")
+			}
+			continue
+		}
+		if value == "" && param.AllowEmptyValue {
+			continue
+		}
+		schema, err := v.checker.paramSchema(param)
+		if err != nil {
+			add(in, param.Name, err.Error())
+			continue
+		}
+		for _, sv := range v.checker.check(schema, value, "") {
+			add(in, param.Name, sv.message)
+		}
+	}
+
+	for _, sv := range v.body(r, op.RequestBody) {
+		add("body", sv.pointer, sv.message)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &RequestError{
+		Message:    "invalid request",
+		Violations: violations,
+	}
+}
+
+// requestValues return raw values of param in request, query is the parsed query of request
+func requestValues(r *http.Request, query url.Values, pathValues map[string]string, param *Param) []string {
+	switch param.In {
+	case PathParam:
+		if value, ok := pathValues[param.Name]; ok {
+			return []string{value}
+		}
+	case QueryParam:
+		return query[param.Name]
+	case HeaderParam:
+		return r.Header[http.CanonicalHeaderKey(param.Name)]
+	case CookieParam:
+		if cookie, err := r.Cookie(param.Name); err == nil {
+			return []string{cookie.Value}
+		}
+	}
+	return nil
+}
+
+// body validates request body, body of request is replaced so it can still be read
+func (v *requestValidator) body(r *http.Request, body *RequestBody) []schemaViolation {
+	if body == nil {
+		return nil
+	}
+	var data []byte
+	if r.Body != nil {
+		var err error
+		data, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		if err != nil {
+			return []schemaViolation{{"", "failed to read body:" + err.Error()}}
+		}
+	}
+	if len(data) == 0 {
+		if body.Required {
+			return []schemaViolation{{"", "required"}}
+		}
+		return nil
+	}
+	mimeType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return []schemaViolation{{"", "invalid Content-Type:" + err.Error()}}
+	}
+	media := matchMediaType(body.Content, mimeType)
+	if media == nil {
+		return []schemaViolation{{"", "unsupported Content-Type " + mimeType}}
+	}
+	if !isJSONMime(mimeType) || media.Schema == nil {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []schemaViolation{{"", "invalid JSON:" + err.Error()}}
+	}
+	return v.checker.check(media.Schema, value, "")
+}
+
+// matchMediaType find media type in content, wildcards like image/* and */* are supported
+func matchMediaType(content mediaTypeMap, mimeType string) *MediaType {
+	if media, ok := content[mimeType]; ok {
+		return media
+	}
+	if i := strings.Index(mimeType, "/"); i >= 0 {
+		if media, ok := content[mimeType[:i]+"/*"]; ok {
+			return media
+		}
+	}
+	return content["*/*"]
+}
+
+func isJSONMime(mimeType string) bool {
+	return mimeType == MimeJSON || strings.HasSuffix(mimeType, "+json")
+}
+
+func scalarValue(schema *Schema, value string) (interface{}, error) {
+	if schema == nil {
+		return value, nil
+	}
+	switch schema.Type {
+	case "integer":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errInvalidValue(value, schema.Type)
+		}
+		return i, nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errInvalidValue(value, schema.Type)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errInvalidValue(value, schema.Type)
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+func errInvalidValue(value, typ string) error {
+	return fmt.Errorf("invalid %s value %q", typ, value)
+}
@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bookQuery struct {
+	Name  string   `json:"name" validate:"required,min=1,max=8"`
+	Price float64  `json:"price" validate:"min=0"`
+	Tags  []string `json:"tags"`
+}
+
+func newValidatedHandler(t *testing.T, opts RequestValidatorOptions) http.Handler {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.Route("/books", func(r Router) {
+		r.GET("", "List books", "List books").
+			WithParam(NewQueryParam("limit", "Max books", 10).SetRequired()).
+			WithParam(&Param{
+				Name: "ids",
+				In:   QueryParam,
+				Schema: &Schema{
+					Type:  "array",
+					Items: &Schema{Type: "integer"},
+				},
+			}).
+			Returns(200, "Books", "", nil)
+		r.Route("/{id}", func(r Router) {
+			r.WithParam(&Param{
+				Name:     "id",
+				In:       PathParam,
+				Required: true,
+				Schema: &Schema{
+					Type:    "integer",
+					Minimum: 1,
+				},
+			})
+			r.PUT("", "Update book", "Update book").
+				ReadJSON("Book", true, "bookQuery", &bookQuery{}).
+				Returns(200, "Book", "", nil)
+		})
+	})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	})
+	return RequestValidator(o, opts)(next)
+}
+
+func TestRequestValidator(t *testing.T) {
+	h := newValidatedHandler(t, RequestValidatorOptions{})
+	testCases := []struct {
+		desc   string
+		method string
+		target string
+		body   string
+		code   int
+		expect string
+	}{
+		{
+			desc:   "valid-query",
+			method: "GET",
+			target: "/books?limit=5&ids=1,2",
+			code:   200,
+		},
+		{
+			desc:   "invalid-query",
+			method: "GET",
+			target: "/books?ids=1,b",
+			code:   400,
+			expect: `{"message":"invalid request","violations":[{"in":"query","name":"limit","message":"required"},{"in":"query","name":"ids","message":"invalid integer value \"b\""}]}`,
+		},
+		{
+			desc:   "valid-body",
+			method: "PUT",
+			target: "/books/1",
+			body:   `{"name":"go","price":1.5,"tags":["a"]}`,
+			code:   200,
+			expect: `{"name":"go","price":1.5,"tags":["a"]}`,
+		},
+		{
+			desc:   "invalid-body",
+			method: "PUT",
+			target: "/books/0",
+			body:   `{"name":"too long name","price":-1,"tags":[1]}`,
+			code:   400,
+			expect: `{"message":"invalid request","violations":[{"in":"path","name":"id","message":"value 0 is less than minimum 1"},{"in":"body","name":"/name","message":"length 13 is greater than maxLength 8"},{"in":"body","name":"/price","message":"value -1 is less than minimum 0"},{"in":"body","name":"/tags/0","message":"expect string, got number"}]}`,
+		},
+		{
+			desc:   "missing-body",
+			method: "PUT",
+			target: "/books/1",
+			code:   400,
+			expect: `{"message":"invalid request","violations":[{"in":"body","message":"required"}]}`,
+		},
+		{
+			desc:   "unknown-path",
+			method: "GET",
+			target: "/authors",
+			code:   200,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tC.method, tC.target, strings.NewReader(tC.body))
+			req.Header.Set("Content-Type", MimeJSON)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tC.code {
+				t.Fatalf("Expect code %d, got %d: %s", tC.code, w.Code, w.Body.String())
+			}
+			if tC.expect != "" && w.Body.String() != tC.expect {
+				t.Fatalf("Expect:\n%s\nGot:\n%s", tC.expect, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestValidatorRejectUnknown(t *testing.T) {
+	h := newValidatedHandler(t, RequestValidatorOptions{RejectUnknown: true})
+	for target, code := range map[string]int{
+		"/authors": http.StatusNotFound,
+		"/books/1": http.StatusMethodNotAllowed,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != code {
+			t.Errorf("Expect code %d for %s, got %d", code, target, w.Code)
+		}
+	}
+}
+
+func TestCheckNullOfWrappedRef(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := o.MustGetSchema("book", &Book{})
+	checker := newValueChecker(o)
+	// Wrappers of refs have no type, null is checked against the ref
+	wrapped := &Schema{AllOf: []*Schema{ref}, Description: "Book"}
+	if violations := checker.check(wrapped, nil, ""); len(violations) != 1 || violations[0].message != "null is not allowed" {
+		t.Fatalf("Expect null rejected, got %v", violations)
+	}
+	if violations := checker.check(&Schema{OneOf: []*Schema{ref}}, nil, ""); len(violations) != 1 {
+		t.Fatalf("Expect null rejected by oneOf, got %v", violations)
+	}
+	wrapped.Nullable = true
+	if violations := checker.check(wrapped, nil, ""); len(violations) != 0 {
+		t.Fatalf("Expect null allowed by nullable wrapper, got %v", violations)
+	}
+}
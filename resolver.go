@@ -0,0 +1,589 @@
+package openapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RefError is returned when a $ref cannot be resolved
+type RefError struct {
+	Ref string
+	Err error
+}
+
+func (e *RefError) Error() string {
+	return "failed to resolve $ref " + e.Ref + ":" + e.Err.Error()
+}
+
+// Resolver follows $ref to the objects they point to.
+// Refs to other files are loaded relative to the file the ref appears in,
+// refs of the root document are relative to the dir given to Resolver.
+type Resolver struct {
+	root *OpenAPI
+	dir  string
+	// ReadFile load external files, ioutil.ReadFile by default
+	ReadFile  func(filename string) ([]byte, error)
+	files     map[string]*refFile
+	locations map[*OpenAPI]string
+}
+
+// refFile is an external file that has been loaded
+type refFile struct {
+	doc      *OpenAPI
+	raw      []byte
+	schema   *Schema
+	param    *Param
+	response *Response
+}
+
+// refTarget is where a $ref points to
+type refTarget struct {
+	doc    *OpenAPI
+	file   string
+	tokens []string
+}
+
+func (t *refTarget) String() string {
+	return t.file + "#/" + strings.Join(t.tokens, "/")
+}
+
+// Resolver create a resolver for document, dir is where relative file refs are looked up
+func (o *OpenAPI) Resolver(dir string) *Resolver {
+	return &Resolver{
+		root:      o,
+		dir:       dir,
+		ReadFile:  ioutil.ReadFile,
+		files:     make(map[string]*refFile),
+		locations: make(map[*OpenAPI]string),
+	}
+}
+
+// Schema follows $ref of schema until a concrete schema is found
+func (r *Resolver) Schema(s *Schema) (*Schema, error) {
+	resolved, _, err := r.schema(r.docOf(s.root), s)
+	return resolved, err
+}
+
+// Param follows $ref of param until a concrete param is found
+func (r *Resolver) Param(p *Param) (*Param, error) {
+	resolved, _, err := r.param(r.docOf(p.root), p)
+	return resolved, err
+}
+
+// Response follows $ref of a response of root document until a concrete response is found
+func (r *Resolver) Response(resp *Response) (*Response, error) {
+	resolved, _, err := r.response(r.root, resp)
+	return resolved, err
+}
+
+func (r *Resolver) docOf(o *OpenAPI) *OpenAPI {
+	if o == nil {
+		return r.root
+	}
+	return o
+}
+
+func (r *Resolver) schema(doc *OpenAPI, s *Schema) (*Schema, *OpenAPI, error) {
+	visited := make(map[string]bool)
+	for s.Ref != "" {
+		ref := s.Ref
+		t, err := r.follow(doc, ref, visited)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(t.tokens) == 0 {
+			s, err = r.files[t.file].wholeSchema()
+		} else {
+			s, err = schemaAt(t)
+		}
+		if err != nil {
+			return nil, nil, &RefError{Ref: ref, Err: err}
+		}
+		doc = t.doc
+	}
+	return s, doc, nil
+}
+
+func (r *Resolver) param(doc *OpenAPI, p *Param) (*Param, *OpenAPI, error) {
+	visited := make(map[string]bool)
+	for p.Ref != "" {
+		ref := p.Ref
+		t, err := r.follow(doc, ref, visited)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(t.tokens) == 0 {
+			p, err = r.files[t.file].wholeParam()
+		} else {
+			var name string
+			name, err = componentName(t, "parameters")
+			if err == nil {
+				var ok bool
+				if p, ok = t.doc.Components.Parameters[name]; !ok {
+					err = ErrRefNotFound
+				}
+			}
+		}
+		if err != nil {
+			return nil, nil, &RefError{Ref: ref, Err: err}
+		}
+		doc = t.doc
+	}
+	return p, doc, nil
+}
+
+func (r *Resolver) response(doc *OpenAPI, resp *Response) (*Response, *OpenAPI, error) {
+	visited := make(map[string]bool)
+	for resp.Ref != "" {
+		ref := resp.Ref
+		t, err := r.follow(doc, ref, visited)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(t.tokens) == 0 {
+			resp, err = r.files[t.file].wholeResponse()
+		} else {
+			var name string
+			name, err = componentName(t, "responses")
+			if err == nil {
+				var ok bool
+				if resp, ok = t.doc.Components.Responses[name]; !ok {
+					err = ErrRefNotFound
+				}
+			}
+		}
+		if err != nil {
+			return nil, nil, &RefError{Ref: ref, Err: err}
+		}
+		doc = t.doc
+	}
+	return resp, doc, nil
+}
+
+// follow find target of ref appeared in doc, and detect cycles with targets visited before
+func (r *Resolver) follow(doc *OpenAPI, ref string, visited map[string]bool) (*refTarget, error) {
+	t, err := r.target(doc, ref)
+	if err != nil {
+		return nil, &RefError{Ref: ref, Err: err}
+	}
+	if visited[t.String()] {
+		return nil, &RefError{Ref: ref, Err: ErrRefCycle}
+	}
+	visited[t.String()] = true
+	return t, nil
+}
+
+func (r *Resolver) target(doc *OpenAPI, ref string) (*refTarget, error) {
+	file, pointer := splitRef(ref)
+	t := &refTarget{
+		doc:  doc,
+		file: r.locations[doc],
+	}
+	if file != "" {
+		if strings.Contains(file, "://") {
+			return nil, fmt.Errorf("remote file is not supported")
+		}
+		if !filepath.IsAbs(file) {
+			base := r.dir
+			if t.file != "" {
+				base = filepath.Dir(t.file)
+			}
+			file = filepath.Join(base, file)
+		}
+		f, err := r.load(file)
+		if err != nil {
+			return nil, err
+		}
+		t.doc = f.doc
+		t.file = file
+	}
+	t.tokens = parsePointer(pointer)
+	if len(t.tokens) == 0 && t.file == "" {
+		return nil, fmt.Errorf("ref to the whole root document is not supported")
+	}
+	return t, nil
+}
+
+func (r *Resolver) load(file string) (*refFile, error) {
+	if f, ok := r.files[file]; ok {
+		return f, nil
+	}
+	data, err := r.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is also YAML, so both formats are accepted
+	raw, err := yamlToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s:%s", file, err.Error())
+	}
+	doc, err := parseDocument(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s:%s", file, err.Error())
+	}
+	f := &refFile{
+		doc: doc,
+		raw: raw,
+	}
+	r.files[file] = f
+	r.locations[doc] = file
+	return f, nil
+}
+
+func (f *refFile) wholeSchema() (*Schema, error) {
+	if f.schema == nil {
+		var s Schema
+		if err := json.Unmarshal(f.raw, &s); err != nil {
+			return nil, err
+		}
+		s.SetRoot(f.doc)
+		f.schema = &s
+	}
+	return f.schema, nil
+}
+
+func (f *refFile) wholeParam() (*Param, error) {
+	if f.param == nil {
+		var p Param
+		if err := json.Unmarshal(f.raw, &p); err != nil {
+			return nil, err
+		}
+		f.doc.setParamRoot(&p)
+		f.param = &p
+	}
+	return f.param, nil
+}
+
+func (f *refFile) wholeResponse() (*Response, error) {
+	if f.response == nil {
+		var resp Response
+		if err := json.Unmarshal(f.raw, &resp); err != nil {
+			return nil, err
+		}
+		f.doc.setResponseRoot(&resp)
+		f.response = &resp
+	}
+	return f.response, nil
+}
+
+// splitRef split ref like "common.yaml#/components/schemas/Error" into file and JSON pointer
+func splitRef(ref string) (file, pointer string) {
+	i := strings.Index(ref, "#")
+	if i < 0 {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+func parsePointer(pointer string) []string {
+	pointer = strings.TrimPrefix(pointer, "/")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(pointer, "/")
+	for i, token := range tokens {
+		token = strings.Replace(token, "~1", "/", -1)
+		tokens[i] = strings.Replace(token, "~0", "~", -1)
+	}
+	return tokens
+}
+
+func componentName(t *refTarget, section string) (string, error) {
+	if len(t.tokens) != 3 || t.tokens[0] != "components" || t.tokens[1] != section {
+		return "", fmt.Errorf("only #/components/%s/{name} is supported", section)
+	}
+	return t.tokens[2], nil
+}
+
+// schemaAt find schema in components, the pointer may go further into the schema
+func schemaAt(t *refTarget) (*Schema, error) {
+	if len(t.tokens) < 3 || t.tokens[0] != "components" || t.tokens[1] != "schemas" {
+		return nil, fmt.Errorf("only #/components/schemas/{name} is supported")
+	}
+	s, ok := t.doc.Components.Schemas[t.tokens[2]]
+	if !ok {
+		return nil, ErrRefNotFound
+	}
+	tokens := t.tokens[3:]
+	for i := 0; i < len(tokens) && s != nil; i++ {
+		switch tokens[i] {
+		case "items":
+			s = s.Items
+		case "not":
+			s = s.Not
+		case "additionalProperties":
+			s = s.AdditionalProperties
+		case "properties":
+			if i++; i == len(tokens) {
+				return nil, ErrRefNotFound
+			}
+			s = s.Properties[tokens[i]]
+		case "allOf", "oneOf", "anyOf":
+			list := map[string][]*Schema{
+				"allOf": s.AllOf,
+				"oneOf": s.OneOf,
+				"anyOf": s.AnyOf,
+			}[tokens[i]]
+			if i++; i == len(tokens) {
+				return nil, ErrRefNotFound
+			}
+			n, err := strconv.Atoi(tokens[i])
+			if err != nil || n < 0 || n >= len(list) {
+				return nil, ErrRefNotFound
+			}
+			s = list[n]
+		default:
+			return nil, ErrRefNotFound
+		}
+	}
+	if s == nil {
+		return nil, ErrRefNotFound
+	}
+	return s, nil
+}
+
+// Bundle pull everything referenced from other files into components of the root document,
+// and rewrite those refs to local ones, so the document is self-contained.
+func (r *Resolver) Bundle() error {
+	b := &bundler{
+		Resolver: r,
+		keys:     make(map[string]string),
+	}
+	o := r.root
+	c := o.Components
+	for _, key := range sortedSchemaKeys(c.Schemas) {
+		if err := b.schema(o, c.Schemas[key]); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedParamKeys(c.Parameters) {
+		if err := b.param(o, c.Parameters[key]); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedResponseKeys(c.Responses) {
+		if err := b.response(o, c.Responses[key]); err != nil {
+			return err
+		}
+	}
+	for _, body := range c.RequestBodies {
+		if err := b.content(o, body.Content); err != nil {
+			return err
+		}
+	}
+	paths := make([]string, 0, len(o.Paths))
+	for key := range o.Paths {
+		paths = append(paths, key)
+	}
+	sort.Strings(paths)
+	for _, key := range paths {
+		path := o.Paths[key]
+		for _, param := range path.Parameters {
+			if err := b.param(o, param); err != nil {
+				return err
+			}
+		}
+		for _, method := range pathMethods {
+			op, ok := path.operations[method]
+			if !ok {
+				continue
+			}
+			for _, param := range op.Parameters {
+				if err := b.param(o, param); err != nil {
+					return err
+				}
+			}
+			if op.RequestBody != nil {
+				if err := b.content(o, op.RequestBody.Content); err != nil {
+					return err
+				}
+			}
+			for _, code := range sortedResponseKeys(respMap(op.Responses)) {
+				if err := b.response(o, op.Responses[code]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type bundler struct {
+	*Resolver
+	// target of a ref -> key in components of root document
+	keys map[string]string
+}
+
+// isLocal tells whether ref appeared in doc points to the root document
+func (b *bundler) isLocal(doc *OpenAPI, ref string) bool {
+	file, _ := splitRef(ref)
+	return doc == b.root && file == ""
+}
+
+func (b *bundler) schema(doc *OpenAPI, s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" && !b.isLocal(doc, s.Ref) {
+		t, err := b.target(doc, s.Ref)
+		if err != nil {
+			return &RefError{Ref: s.Ref, Err: err}
+		}
+		key, ok := b.keys[t.String()]
+		if !ok {
+			resolved, resolvedDoc, err := b.Resolver.schema(doc, s)
+			if err != nil {
+				return err
+			}
+			key = uniqueKey(refName(t), func(k string) bool {
+				_, exists := b.root.Components.Schemas[k]
+				return exists
+			})
+			// Register before going deeper, so recursive refs end up here
+			b.keys[t.String()] = key
+			b.root.Components.Schemas[key] = resolved
+			resolved.key = key
+			if err := b.schema(resolvedDoc, resolved); err != nil {
+				return err
+			}
+		}
+		s.Ref = schemaRefPrefix + key
+		s.key = key
+	}
+	s.root = b.root
+	for _, key := range sortedSchemaKeys(s.Properties) {
+		if err := b.schema(doc, s.Properties[key]); err != nil {
+			return err
+		}
+	}
+	children := []*Schema{s.Items, s.Not, s.AdditionalProperties}
+	children = append(children, s.AllOf...)
+	children = append(children, s.OneOf...)
+	children = append(children, s.AnyOf...)
+	for _, child := range children {
+		if err := b.schema(doc, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *bundler) param(doc *OpenAPI, p *Param) error {
+	if p.Ref != "" && !b.isLocal(doc, p.Ref) {
+		t, err := b.target(doc, p.Ref)
+		if err != nil {
+			return &RefError{Ref: p.Ref, Err: err}
+		}
+		key, ok := b.keys[t.String()]
+		if !ok {
+			resolved, resolvedDoc, err := b.Resolver.param(doc, p)
+			if err != nil {
+				return err
+			}
+			key = uniqueKey(refName(t), func(k string) bool {
+				_, exists := b.root.Components.Parameters[k]
+				return exists
+			})
+			b.keys[t.String()] = key
+			b.root.Components.Parameters[key] = resolved
+			if err := b.param(resolvedDoc, resolved); err != nil {
+				return err
+			}
+		}
+		p.Ref = "#/components/parameters/" + key
+	}
+	p.root = b.root
+	if err := b.schema(doc, p.Schema); err != nil {
+		return err
+	}
+	return b.content(doc, p.Content)
+}
+
+func (b *bundler) response(doc *OpenAPI, resp *Response) error {
+	if resp.Ref != "" && !b.isLocal(doc, resp.Ref) {
+		t, err := b.target(doc, resp.Ref)
+		if err != nil {
+			return &RefError{Ref: resp.Ref, Err: err}
+		}
+		key, ok := b.keys[t.String()]
+		if !ok {
+			resolved, resolvedDoc, err := b.Resolver.response(doc, resp)
+			if err != nil {
+				return err
+			}
+			key = uniqueKey(refName(t), func(k string) bool {
+				_, exists := b.root.Components.Responses[k]
+				return exists
+			})
+			b.keys[t.String()] = key
+			b.root.Components.Responses[key] = resolved
+			if err := b.response(resolvedDoc, resolved); err != nil {
+				return err
+			}
+		}
+		resp.Ref = "#/components/responses/" + key
+	}
+	for _, key := range sortedParamKeys(resp.Headers) {
+		if err := b.param(doc, resp.Headers[key]); err != nil {
+			return err
+		}
+	}
+	return b.content(doc, resp.Content)
+}
+
+func (b *bundler) content(doc *OpenAPI, content mediaTypeMap) error {
+	for _, media := range content {
+		if err := b.schema(doc, media.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refName is the name of target used as component key
+func refName(t *refTarget) string {
+	if len(t.tokens) != 0 {
+		return t.tokens[len(t.tokens)-1]
+	}
+	base := filepath.Base(t.file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func uniqueKey(name string, exists func(key string) bool) string {
+	key := name
+	for i := 2; exists(key); i++ {
+		key = name + strconv.Itoa(i)
+	}
+	return key
+}
+
+func sortedSchemaKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedParamKeys(m paramMap) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedResponseKeys(m respMap) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
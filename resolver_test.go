@@ -0,0 +1,214 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var refFiles = map[string]string{
+	"api.yaml": `
+openapi: 3.0.0
+info:
+  title: testing
+  version: v1.0
+paths:
+  /books/{id}:
+    get:
+      parameters:
+        - $ref: 'params.yaml#/components/parameters/id'
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/book'
+        default:
+          $ref: 'common.yaml#/components/responses/Error'
+components:
+  schemas:
+    book:
+      type: object
+      properties:
+        author:
+          $ref: 'common/author.yaml'
+        self:
+          $ref: '#/components/schemas/book'
+    alias:
+      $ref: '#/components/schemas/book'
+    loopA:
+      $ref: '#/components/schemas/loopB'
+    loopB:
+      $ref: '#/components/schemas/loopA'
+`,
+	"params.yaml": `
+components:
+  parameters:
+    id:
+      name: id
+      in: path
+      required: true
+      schema:
+        type: string
+`,
+	"common.yaml": `
+components:
+  responses:
+    Error:
+      description: error
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+  schemas:
+    Error:
+      type: object
+      properties:
+        message:
+          type: string
+`,
+	"common/author.yaml": `
+type: object
+properties:
+  name:
+    type: string
+  books:
+    type: array
+    items:
+      $ref: '../common.yaml#/components/schemas/Error'
+`,
+}
+
+func newTestResolver(t *testing.T) (*OpenAPI, *Resolver) {
+	o, err := ParseYAML([]byte(refFiles["api.yaml"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := o.Resolver("specs")
+	r.ReadFile = func(filename string) ([]byte, error) {
+		rel, err := filepath.Rel("specs", filename)
+		if err != nil {
+			return nil, err
+		}
+		content, ok := refFiles[filepath.ToSlash(rel)]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+	return o, r
+}
+
+func TestResolveSchema(t *testing.T) {
+	o, r := newTestResolver(t)
+	book := o.Components.Schemas["book"]
+
+	s, err := r.Schema(o.GetSchema("alias"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != book {
+		t.Fatal("alias should resolve to book through nested refs")
+	}
+
+	author, err := r.Schema(book.Properties["author"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := author.Properties["name"]; !ok {
+		t.Fatal("author not loaded from external file")
+	}
+	// Ref inside external file is relative to that file
+	errSchema, err := r.Schema(author.Properties["books"].Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := errSchema.Properties["message"]; !ok {
+		t.Fatal("Error schema not loaded from common.yaml")
+	}
+
+	_, err = r.Schema(o.GetSchema("loopA"))
+	refErr, ok := err.(*RefError)
+	if !ok || refErr.Err != ErrRefCycle {
+		t.Fatalf("expect cycle error, got %v", err)
+	}
+
+	_, err = r.Schema(&Schema{Ref: "#/components/schemas/none"})
+	refErr, ok = err.(*RefError)
+	if !ok || refErr.Err != ErrRefNotFound {
+		t.Fatalf("expect not found error, got %v", err)
+	}
+}
+
+func TestResolveParamAndResponse(t *testing.T) {
+	o, r := newTestResolver(t)
+	op := o.Paths["/books/{id}"].operations["get"]
+
+	param, err := r.Param(op.Parameters[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.Name != "id" || param.In != PathParam {
+		t.Fatalf("unexpected param %+v", param)
+	}
+
+	resp, err := r.Response(op.Responses["default"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Description != "error" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	o, r := newTestResolver(t)
+	if err := r.Bundle(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := o.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bundled document resolves without any file
+	bundled, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := bundled.Resolver("")
+	local.ReadFile = func(filename string) ([]byte, error) {
+		t.Fatal("unexpected file access:", filename)
+		return nil, nil
+	}
+
+	op := bundled.Paths["/books/{id}"].operations["get"]
+	if op.Parameters[0].Ref != "#/components/parameters/id" {
+		t.Fatal("unexpected param ref", op.Parameters[0].Ref)
+	}
+	if op.Responses["default"].Ref != "#/components/responses/Error" {
+		t.Fatal("unexpected response ref", op.Responses["default"].Ref)
+	}
+	if _, err := local.Param(op.Parameters[0]); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := local.Response(op.Responses["default"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content[MimeJSON].Schema.Ref != "#/components/schemas/Error" {
+		t.Fatal("unexpected schema ref", resp.Content[MimeJSON].Schema.Ref)
+	}
+
+	author := bundled.Components.Schemas["book"].Properties["author"]
+	if author.Ref != "#/components/schemas/author" {
+		t.Fatal("unexpected author ref", author.Ref)
+	}
+	s, err := local.Schema(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Properties["books"].Items.Ref != "#/components/schemas/Error" {
+		t.Fatal("unexpected items ref", s.Properties["books"].Items.Ref)
+	}
+}
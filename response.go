@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponseError lists all violations of a response against its operation
+type ResponseError struct {
+	Method     string       `json:"method"`
+	Path       string       `json:"path"`
+	Code       int          `json:"code"`
+	Violations []*Violation `json:"violations"`
+}
+
+func (e *ResponseError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.In + " " + v.Name + ": " + v.Message
+	}
+	return "invalid response of " + strings.ToUpper(e.Method) + " " + e.Path + " with code " +
+		strconv.Itoa(e.Code) + ": " + strings.Join(msgs, "; ")
+}
+
+// ResponseValidatorOptions options for response validator
+type ResponseValidatorOptions struct {
+	// Strict buffers responses, and replace invalid ones with code 500 and JSON of ResponseError.
+	// It's meant for tests, otherwise responses are passed on as they are, and flushed or hijacked as usual
+	Strict bool
+	// SampleRate is the ratio of responses to be validated, all responses are validated if it's 0
+	SampleRate float64
+	// OnError is called for each invalid response, the error is logged by default
+	OnError func(r *http.Request, err *ResponseError)
+}
+
+// ResponseValidator create middleware that checks status code, Content-Type and body of
+// responses against the responses declared by operations.
+// Paths of document are collected when it's created, so add all routes before it
+func ResponseValidator(o *OpenAPI, opts ResponseValidatorOptions) func(next http.Handler) http.Handler {
+	checker := newValueChecker(o)
+	matcher := newPathMatcher(o.Paths)
+	if opts.OnError == nil {
+		opts.OnError = func(r *http.Request, err *ResponseError) {
+			log.Println(err.Error())
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.SampleRate > 0 && rand.Float64() >= opts.SampleRate {
+				next.ServeHTTP(w, r)
+				return
+			}
+			route, _ := matcher.match(r.URL.EscapedPath())
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			op, ok := route.path.operations[strings.ToLower(r.Method)]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &responseRecorder{
+				ResponseWriter: w,
+				buffered:       opts.Strict,
+			}
+			next.ServeHTTP(rec, r)
+			if rec.hijacked {
+				return
+			}
+			if !rec.wroteHeader {
+				rec.WriteHeader(http.StatusOK)
+			}
+
+			media, violations := checkResponse(checker, op, rec.code, w.Header(), rec.size != 0)
+			if media != nil {
+				violations = append(violations, checkBody(checker, media, rec.body.Bytes())...)
+			}
+			err := newResponseError(op, rec.code, violations)
+			if err != nil {
+				opts.OnError(r, err)
+			}
+			if !opts.Strict {
+				return
+			}
+			if err != nil {
+				raw, _ := json.Marshal(err)
+				w.Header().Del("Content-Length")
+				w.Header().Set("Content-Type", MimeJSON)
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write(raw)
+				return
+			}
+			w.WriteHeader(rec.code)
+			w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// responseRecorder keeps a copy of response, which is not passed on if buffered.
+// Otherwise only JSON bodies are copied, since other bodies are not validated
+type responseRecorder struct {
+	http.ResponseWriter
+	buffered    bool
+	recorded    bool
+	wroteHeader bool
+	hijacked    bool
+	code        int
+	size        int
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.code = code
+	if rec.buffered {
+		rec.recorded = true
+		return
+	}
+	mimeType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	rec.recorded = isJSONMime(mimeType)
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.size += len(data)
+	if rec.recorded {
+		rec.body.Write(data)
+	}
+	if rec.buffered {
+		return len(data), nil
+	}
+	return rec.ResponseWriter.Write(data)
+}
+
+// Flush pass buffered data to client, unless the whole response is held back for validation
+func (rec *responseRecorder) Flush() {
+	flusher, ok := rec.ResponseWriter.(http.Flusher)
+	if !ok || rec.buffered {
+		return
+	}
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	flusher.Flush()
+}
+
+// Hijack take over the connection, the response is not validated then
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	rec.hijacked = true
+	return hijacker.Hijack()
+}
+
+// ValidateResponse checks status code, headers and body of a response against the operation
+func (o *Operation) ValidateResponse(code int, header http.Header, body []byte) error {
+	if err := validateResponse(newValueChecker(o.Root()), o, code, header, body); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateResponse(checker *valueChecker, op *Operation, code int, header http.Header, body []byte) *ResponseError {
+	media, violations := checkResponse(checker, op, code, header, len(body) != 0)
+	if media != nil {
+		violations = append(violations, checkBody(checker, media, body)...)
+	}
+	return newResponseError(op, code, violations)
+}
+
+func newResponseError(op *Operation, code int, violations []*Violation) *ResponseError {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ResponseError{
+		Method:     op.method,
+		Path:       op.path.path,
+		Code:       code,
+		Violations: violations,
+	}
+}
+
+// checkResponse checks status code and headers of response. If the body is JSON to be checked,
+// the media type declaring its schema is returned
+func checkResponse(checker *valueChecker, op *Operation, code int, header http.Header, hasBody bool) (*MediaType, []*Violation) {
+	resp := findResponse(op.Responses, code)
+	if resp == nil {
+		return nil, []*Violation{{In: "status", Message: "undeclared status code " + strconv.Itoa(code)}}
+	}
+	resp, err := checker.resolveResponse(resp)
+	if err != nil {
+		return nil, []*Violation{{In: "status", Message: err.Error()}}
+	}
+
+	var violations []*Violation
+	for _, name := range sortedParamKeys(resp.Headers) {
+		h, err := checker.resolveParam(resp.Headers[name])
+		if err != nil {
+			violations = append(violations, &Violation{In: "header", Name: name, Message: err.Error()})
+			continue
+		}
+		if h.Required && header.Get(name) == "" {
+			violations = append(violations, &Violation{In: "header", Name: name, Message: "required"})
+		}
+	}
+
+	if !hasBody {
+		return nil, violations
+	}
+	if len(resp.Content) == 0 {
+		return nil, append(violations, &Violation{In: "body", Message: "no content is declared for the response"})
+	}
+	mimeType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil, append(violations, &Violation{In: "header", Name: "Content-Type", Message: "invalid Content-Type:" + err.Error()})
+	}
+	media := matchMediaType(resp.Content, mimeType)
+	if media == nil {
+		return nil, append(violations, &Violation{In: "header", Name: "Content-Type", Message: "undeclared Content-Type " + mimeType})
+	}
+	if !isJSONMime(mimeType) || media.Schema == nil {
+		return nil, violations
+	}
+	return media, violations
+}
+
+// checkBody checks JSON body against schema of media type
+func checkBody(checker *valueChecker, media *MediaType, body []byte) []*Violation {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []*Violation{{In: "body", Message: "invalid JSON:" + err.Error()}}
+	}
+	var violations []*Violation
+	for _, sv := range checker.check(media.Schema, value, "") {
+		violations = append(violations, &Violation{In: "body", Name: sv.pointer, Message: sv.message})
+	}
+	return violations
+}
+
+// findResponse find response by exact code, then range like 2XX, then default
+func findResponse(responses Responses, code int) *Response {
+	strCode := strconv.Itoa(code)
+	if resp, ok := responses[strCode]; ok {
+		return resp
+	}
+	if resp, ok := responses[strCode[:1]+"XX"]; ok {
+		return resp
+	}
+	return responses["default"]
+}
@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var bookReplies = map[string]struct {
+	code        int
+	contentType string
+	body        string
+}{
+	"valid":        {200, MimeJSON, `{"name":"go","author":"rob","date":"2009-11-10"}`},
+	"invalid-body": {200, MimeJSON, `{"name":"","date":"today"}`},
+	"undeclared":   {418, MimeJSON, `{}`},
+	"content-type": {200, "text/plain", `go`},
+	"not-found":    {404, MimeJSON, `{"code":404,"message":"not found"}`},
+}
+
+func newResponseValidated(t *testing.T, opts ResponseValidatorOptions) http.Handler {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.GET("/books/{id}", "Get book", "Get book").
+		WithParam(NewPathParam("id", "ID of book").SetRequired()).
+		Returns(200, "Book", "Book", &Book{}).
+		Returns(404, "Book not found", "ReplyError", &ReplyError{})
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := bookReplies[r.URL.Query().Get("reply")]
+		w.Header().Set("Content-Type", reply.contentType)
+		w.WriteHeader(reply.code)
+		w.Write([]byte(reply.body))
+	})
+	return ResponseValidator(o, opts)(next)
+}
+
+func TestResponseValidatorStrict(t *testing.T) {
+	var errs []*ResponseError
+	h := newResponseValidated(t, ResponseValidatorOptions{
+		Strict: true,
+		OnError: func(r *http.Request, err *ResponseError) {
+			errs = append(errs, err)
+		},
+	})
+	testCases := []struct {
+		reply  string
+		code   int
+		expect string
+	}{
+		{
+			reply:  "valid",
+			code:   200,
+			expect: bookReplies["valid"].body,
+		},
+		{
+			reply:  "not-found",
+			code:   404,
+			expect: bookReplies["not-found"].body,
+		},
+		{
+			reply:  "invalid-body",
+			code:   500,
+			expect: `{"method":"get","path":"/books/{id}","code":200,"violations":[{"in":"body","message":"missing required property author"},{"in":"body","name":"/date","message":"invalid date:parsing time \"today\" as \"2006-01-02\": cannot parse \"today\" as \"2006\""},{"in":"body","name":"/name","message":"length 0 is less than minLength 1"}]}`,
+		},
+		{
+			reply:  "undeclared",
+			code:   500,
+			expect: `{"method":"get","path":"/books/{id}","code":418,"violations":[{"in":"status","message":"undeclared status code 418"}]}`,
+		},
+		{
+			reply:  "content-type",
+			code:   500,
+			expect: `{"method":"get","path":"/books/{id}","code":200,"violations":[{"in":"header","name":"Content-Type","message":"undeclared Content-Type text/plain"}]}`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.reply, func(t *testing.T) {
+			errs = nil
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "/books/1?reply="+tC.reply, nil))
+			if w.Code != tC.code {
+				t.Fatalf("Expect code %d, got %d: %s", tC.code, w.Code, w.Body.String())
+			}
+			if w.Body.String() != tC.expect {
+				t.Fatalf("Expect:\n%s\nGot:\n%s", tC.expect, w.Body.String())
+			}
+			if (tC.code == 500) != (len(errs) == 1) {
+				t.Fatalf("Expect OnError called for invalid response only, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestResponseValidatorLogOnly(t *testing.T) {
+	var errs []*ResponseError
+	h := newResponseValidated(t, ResponseValidatorOptions{
+		OnError: func(r *http.Request, err *ResponseError) {
+			errs = append(errs, err)
+		},
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/books/1?reply=undeclared", nil))
+	if w.Code != 418 || w.Body.String() != "{}" {
+		t.Fatalf("Expect response passed on, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(errs) != 1 || errs[0].Code != 418 {
+		t.Fatalf("Expect error of code 418, got %v", errs)
+	}
+}
+
+func TestResponseValidatorSampling(t *testing.T) {
+	var errs []*ResponseError
+	h := newResponseValidated(t, ResponseValidatorOptions{
+		SampleRate: 1e-9,
+		OnError: func(r *http.Request, err *ResponseError) {
+			errs = append(errs, err)
+		},
+	})
+	for i := 0; i < 10; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/books/1?reply=undeclared", nil))
+	}
+	if len(errs) != 0 {
+		t.Fatalf("Expect responses not sampled, got %d errors", len(errs))
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := o.AddPath("/books", "Books", "").AddOperation("get").
+		Returns(200, "Books", "Book", []Book{})
+	op.Responses["default"] = &Response{Description: "Unexpected error"}
+
+	header := http.Header{"Content-Type": []string{MimeJSON + "; charset=utf-8"}}
+	if err := op.ValidateResponse(200, header, []byte(`[{"name":"go","author":"rob","date":"2009-11-10"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.ValidateResponse(200, header, []byte(`[{"name":"go"}]`)); err == nil {
+		t.Fatal("Expect missing author")
+	}
+	if err := op.ValidateResponse(500, header, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.ValidateResponse(500, header, []byte(`{}`)); err == nil {
+		t.Fatal("Expect undeclared body of default response")
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseValidatorStreaming(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.GET("/events", "Events", "").
+		ReturnsNonJSON(200, "Events", "text/event-stream", nil, &Schema{Type: "string"}, nil)
+	r.GET("/socket", "Socket", "").
+		Returns(101, "Switching protocols", "", nil)
+	var errs []*ResponseError
+	h := ResponseValidator(o, ResponseValidatorOptions{
+		OnError: func(r *http.Request, err *ResponseError) {
+			errs = append(errs, err)
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/socket" {
+			w.(http.Hijacker).Hijack()
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		if rec, ok := w.(*responseRecorder); ok && rec.body.Len() != 0 {
+			t.Fatal("Expect body not validated left out of recorder")
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	if !w.Flushed || w.Body.String() != "data: 1\n\n" || len(errs) != 0 {
+		t.Fatalf("Expect events flushed, got %v %s %v", w.Flushed, w.Body.String(), errs)
+	}
+	hw := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(hw, httptest.NewRequest("GET", "/socket", nil))
+	if !hw.hijacked || len(errs) != 0 {
+		t.Fatalf("Expect connection hijacked without validation, got %v", errs)
+	}
+}
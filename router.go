@@ -1,6 +1,7 @@
 package openapi
 
 import (
+	"net/http"
 	"path"
 	"reflect"
 )
@@ -12,6 +13,7 @@ type Router interface {
 	WithParam(param *Param) Router
 	WithPathParam(name, description string) Router
 	WithTags(tags ...string) Router
+	WithSecurity(reqs ...SecurityRequirement) Router
 	Route(path string, fn func(r Router)) Router
 	// HTTP methods
 	GET(path, summary, description string) *Operation
@@ -20,6 +22,9 @@ type Router interface {
 	DELETE(path, summary, description string) *Operation
 	HEAD(path, summary, description string) *Operation
 	PATCH(path, summary, description string) *Operation
+	Method(method, path, summary, description string) *Operation
+	// Handler serves operations of router and its sub routers with handlers bound by Operation.Handle
+	Handler() http.Handler
 }
 
 type router struct {
@@ -27,9 +32,10 @@ type router struct {
 	parent    *router
 	path      string
 	tags      []string
+	security  []SecurityRequirement
 	params    []*Param
 	paths     map[string]*Path
-	subRoutes map[string]*router
+	subRoutes []*router
 }
 
 // NewRouter create a new router
@@ -42,9 +48,8 @@ func newRouter(root *OpenAPI) *router {
 		panic(ErrNoRoot)
 	}
 	return &router{
-		root:      root,
-		paths:     make(map[string]*Path),
-		subRoutes: make(map[string]*router),
+		root:  root,
+		paths: make(map[string]*Path),
 	}
 }
 
@@ -106,20 +111,29 @@ func (r *router) Method(method, path, summary, description string) *Operation {
 
 		reverse(pathParts)
 		fullPath := joinPathParts(pathParts...)
+		newPath.path = fullPath
 		apiPath, exists = r.root.Paths[fullPath]
 		if !exists {
-			r.paths[path] = newPath
 			r.root.Paths[fullPath] = newPath
 			apiPath = newPath
 		}
+		r.paths[path] = apiPath
 	}
 	tags := make([]string, 0)
+	var security []SecurityRequirement
 	retriveUpstream(func(upstream *router) {
 		tags = append(tags, upstream.tags...)
+		// Requirements are alternatives, so the closest ones replace upstream ones instead of widening them
+		if len(security) == 0 {
+			security = upstream.security
+		}
 	})
 	op := apiPath.AddOperation(method)
 	op.Summary = summary
 	op.Description = description
+	if len(security) != 0 {
+		op.WithSecurity(security...)
+	}
 	return op.WithTags(tags...)
 }
 
@@ -148,12 +162,19 @@ func (r *router) WithTags(tags ...string) Router {
 	return r
 }
 
+// WithSecurity add security requirements to operations of router and its sub routers,
+// they replace requirements of upstream routers
+func (r *router) WithSecurity(reqs ...SecurityRequirement) Router {
+	r.security = append(r.security, reqs...)
+	return r
+}
+
 // Route to sub paths. Remember that the returned router is newly created **sub** router
 func (r *router) Route(path string, fn func(r Router)) Router {
 	sub := newRouter(r.root)
 	sub.parent = r
 	sub.path = path
-	r.subRoutes[path] = sub
+	r.subRoutes = append(r.subRoutes, sub)
 	if fn != nil {
 		fn(sub)
 	}
@@ -2,9 +2,17 @@ package openapi
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
+const schemaRefPrefix = "#/components/schemas/"
+
 // SchemaRequired is a special representation for schema field "required",
 // which can be either boolean or an array of propertity names
 type SchemaRequired struct {
@@ -32,13 +40,31 @@ func (s SchemaRequired) MarshalJSON() ([]byte, error) {
 	return []byte("false"), nil
 }
 
+// UnmarshalJSON accepts either a boolean or an array of property names
+func (s *SchemaRequired) UnmarshalJSON(data []byte) error {
+	var required bool
+	if err := json.Unmarshal(data, &required); err == nil {
+		s.Required = required
+		return nil
+	}
+	var props []string
+	if err := json.Unmarshal(data, &props); err != nil {
+		return fmt.Errorf("required must be a boolean or an array of strings:%s", err.Error())
+	}
+	s.Properties = props
+	return nil
+}
+
 // Schema SchemaObject
 type Schema struct {
-	root                 *OpenAPI
-	key                  string
+	root *OpenAPI
+	key  string
+	// propertyOrder keeps properties in the order they are added
+	propertyOrder        []string
 	Ref                  string             `json:"-"`
+	Title                string             `json:"title,omitempty"`
 	Type                 string             `json:"type,omitempty"`
-	Format               string             `json:"format,omitempty" validate:"oneof=int32 int64 float double byte binary date date-time password"`
+	Format               string             `json:"format,omitempty" validate:"oneof=int32 int64 float double byte binary date date-time password email uri uuid ipv4 ipv6"`
 	AllOf                []*Schema          `json:"allOf,omitempty"`
 	OneOf                []*Schema          `json:"oneOf,omitempty"`
 	AnyOf                []*Schema          `json:"anyOf,omitempty"`
@@ -48,13 +74,44 @@ type Schema struct {
 	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
 	Description          string             `json:"description,omitempty"`
 	Default              interface{}        `json:"default,omitempty"`
+	MultipleOf           interface{}        `json:"multipleOf,omitempty"`
 	Maximum              interface{}        `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
 	Minimum              interface{}        `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
 	MaxLength            *int64             `json:"maxLength,omitempty"`
 	MinLength            *int64             `json:"minLength,omitempty"`
+	MaxItems             *int64             `json:"maxItems,omitempty"`
+	MinItems             *int64             `json:"minItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	MaxProperties        *int64             `json:"maxProperties,omitempty"`
+	MinProperties        *int64             `json:"minProperties,omitempty"`
 	Pattern              string             `json:"pattern,omitempty"`
 	Required             *SchemaRequired    `json:"required,omitempty"`
-	Enum                 []string           `json:"enum,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	Discriminator        *Discriminator     `json:"discriminator,omitempty"`
+	XML                  *XML               `json:"xml,omitempty"`
+	ExternalDocs         *ExternalDocs      `json:"externalDocs,omitempty"`
+}
+
+// Discriminator tells which schema of oneOf/anyOf a value matches by one of its properties
+type Discriminator struct {
+	PropertyName string            `json:"propertyName" validate:"required"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
+
+// XML describes how a schema is represented in XML
+type XML struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Attribute bool   `json:"attribute,omitempty"`
+	Wrapped   bool   `json:"wrapped,omitempty"`
 }
 
 // SetRoot recursively set root for schema and all its related schemas
@@ -63,7 +120,7 @@ func (s *Schema) SetRoot(root *OpenAPI) {
 		return
 	}
 	s.root = root
-	for _, v := range s.AnyOf {
+	for _, v := range s.AllOf {
 		v.SetRoot(root)
 	}
 	for _, v := range s.OneOf {
@@ -75,6 +132,9 @@ func (s *Schema) SetRoot(root *OpenAPI) {
 	if s.Not != nil {
 		s.Not.SetRoot(root)
 	}
+	if s.Items != nil {
+		s.Items.SetRoot(root)
+	}
 	for _, v := range s.Properties {
 		v.SetRoot(root)
 	}
@@ -86,28 +146,46 @@ func (s *Schema) SetRoot(root *OpenAPI) {
 // MarshalJSON turns
 func (s Schema) MarshalJSON() ([]byte, error) {
 	if s.Ref != "" {
-		return []byte(`{"$ref":` + strconv.Quote(s.Ref) + `}`), nil
+		return marshalRef(s.Ref), nil
 	}
 	mirror := struct {
-		Type                 string             `json:"type,omitempty"`
-		Format               string             `json:"format,omitempty" validate:"oneof=int32 int64 float double byte binary date date-time password"`
-		AllOf                []*Schema          `json:"allOf,omitempty"`
-		OneOf                []*Schema          `json:"oneOf,omitempty"`
-		AnyOf                []*Schema          `json:"anyOf,omitempty"`
-		Not                  *Schema            `json:"not,omitempty"`
-		Items                *Schema            `json:"items,omitempty"`
-		Properties           map[string]*Schema `json:"properties,omitempty"`
-		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
-		Description          string             `json:"description,omitempty"`
-		Default              interface{}        `json:"default,omitempty"`
-		Maximum              interface{}        `json:"maximum,omitempty"`
-		Minimum              interface{}        `json:"minimum,omitempty"`
-		MaxLength            *int64             `json:"maxLength,omitempty"`
-		MinLength            *int64             `json:"minLength,omitempty"`
-		Pattern              string             `json:"pattern,omitempty"`
-		Required             *SchemaRequired    `json:"required,omitempty"`
-		Enum                 []string           `json:"enum,omitempty"`
+		Title                string            `json:"title,omitempty"`
+		Type                 string            `json:"type,omitempty"`
+		Format               string            `json:"format,omitempty" validate:"oneof=int32 int64 float double byte binary date date-time password email uri uuid ipv4 ipv6"`
+		AllOf                []*Schema         `json:"allOf,omitempty"`
+		OneOf                []*Schema         `json:"oneOf,omitempty"`
+		AnyOf                []*Schema         `json:"anyOf,omitempty"`
+		Not                  *Schema           `json:"not,omitempty"`
+		Items                *Schema           `json:"items,omitempty"`
+		Properties           *schemaProperties `json:"properties,omitempty"`
+		AdditionalProperties *Schema           `json:"additionalProperties,omitempty"`
+		Description          string            `json:"description,omitempty"`
+		Default              interface{}       `json:"default,omitempty"`
+		MultipleOf           interface{}       `json:"multipleOf,omitempty"`
+		Maximum              interface{}       `json:"maximum,omitempty"`
+		ExclusiveMaximum     bool              `json:"exclusiveMaximum,omitempty"`
+		Minimum              interface{}       `json:"minimum,omitempty"`
+		ExclusiveMinimum     bool              `json:"exclusiveMinimum,omitempty"`
+		MaxLength            *int64            `json:"maxLength,omitempty"`
+		MinLength            *int64            `json:"minLength,omitempty"`
+		MaxItems             *int64            `json:"maxItems,omitempty"`
+		MinItems             *int64            `json:"minItems,omitempty"`
+		UniqueItems          bool              `json:"uniqueItems,omitempty"`
+		MaxProperties        *int64            `json:"maxProperties,omitempty"`
+		MinProperties        *int64            `json:"minProperties,omitempty"`
+		Pattern              string            `json:"pattern,omitempty"`
+		Required             *SchemaRequired   `json:"required,omitempty"`
+		Enum                 []interface{}     `json:"enum,omitempty"`
+		Nullable             bool              `json:"nullable,omitempty"`
+		ReadOnly             bool              `json:"readOnly,omitempty"`
+		WriteOnly            bool              `json:"writeOnly,omitempty"`
+		Example              interface{}       `json:"example,omitempty"`
+		Deprecated           bool              `json:"deprecated,omitempty"`
+		Discriminator        *Discriminator    `json:"discriminator,omitempty"`
+		XML                  *XML              `json:"xml,omitempty"`
+		ExternalDocs         *ExternalDocs     `json:"externalDocs,omitempty"`
 	}{
+		Title:                s.Title,
 		Type:                 s.Type,
 		Format:               s.Format,
 		AllOf:                s.AllOf,
@@ -115,21 +193,114 @@ func (s Schema) MarshalJSON() ([]byte, error) {
 		AnyOf:                s.AnyOf,
 		Not:                  s.Not,
 		Items:                s.Items,
-		Properties:           s.Properties,
+		Properties:           newSchemaProperties(s.propertyOrder, s.Properties),
 		AdditionalProperties: s.AdditionalProperties,
 		Description:          s.Description,
 		Default:              s.Default,
+		MultipleOf:           s.MultipleOf,
 		Maximum:              s.Maximum,
+		ExclusiveMaximum:     s.ExclusiveMaximum,
 		Minimum:              s.Minimum,
+		ExclusiveMinimum:     s.ExclusiveMinimum,
 		MaxLength:            s.MaxLength,
 		MinLength:            s.MinLength,
+		MaxItems:             s.MaxItems,
+		MinItems:             s.MinItems,
+		UniqueItems:          s.UniqueItems,
+		MaxProperties:        s.MaxProperties,
+		MinProperties:        s.MinProperties,
 		Pattern:              s.Pattern,
 		Required:             s.Required,
 		Enum:                 s.Enum,
+		Nullable:             s.Nullable,
+		ReadOnly:             s.ReadOnly,
+		WriteOnly:            s.WriteOnly,
+		Example:              s.Example,
+		Deprecated:           s.Deprecated,
+		Discriminator:        s.Discriminator,
+		XML:                  s.XML,
+		ExternalDocs:         s.ExternalDocs,
 	}
 	return json.Marshal(&mirror)
 }
 
+// schemaProperties marshal properties in the order they are added,
+// properties not added by WithProperty follow in alphabetical order
+type schemaProperties struct {
+	order []string
+	props map[string]*Schema
+}
+
+func newSchemaProperties(order []string, props map[string]*Schema) *schemaProperties {
+	if len(props) == 0 {
+		return nil
+	}
+	return &schemaProperties{
+		order: order,
+		props: props,
+	}
+}
+
+// MarshalJSON marshal properties in order
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	all := make([]string, 0, len(p.props))
+	for key := range p.props {
+		all = append(all, key)
+	}
+	keys := orderedKeys(p.order, func(key string) bool {
+		_, ok := p.props[key]
+		return ok
+	}, all)
+	return marshalOrdered(keys, func(key string) interface{} {
+		return p.props[key]
+	})
+}
+
+// UnmarshalJSON read a schema or a $ref to another schema
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	ref, err := unmarshalRef(data)
+	if err != nil {
+		return err
+	}
+	if ref != "" {
+		*s = Schema{Ref: ref}
+		if strings.HasPrefix(ref, schemaRefPrefix) {
+			s.key = strings.TrimPrefix(ref, schemaRefPrefix)
+		}
+		return nil
+	}
+	// schemaAlias has no methods, so it will not call back into UnmarshalJSON
+	type schemaAlias Schema
+	var alias schemaAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*s = Schema(alias)
+	if len(s.Properties) != 0 {
+		s.propertyOrder = propertyOrder(data)
+	}
+	return nil
+}
+
+// propertyOrder returns keys of properties in the order they appear in a schema object
+func propertyOrder(data []byte) []string {
+	var order []string
+	iter := jsoniter.ParseBytes(json, data)
+	iter.ReadMapCB(func(iter *jsoniter.Iterator, field string) bool {
+		if field != "properties" {
+			iter.Skip()
+			return true
+		}
+		iter.ReadMapCB(func(iter *jsoniter.Iterator, key string) bool {
+			order = append(order, key)
+			iter.Skip()
+			return true
+		})
+		return false
+	})
+	return order
+}
+
 // NewSchema create new schema
 func NewSchema(schemaType string) *Schema {
 	return &Schema{
@@ -146,6 +317,7 @@ func (s *Schema) WithDescription(description string) *Schema {
 
 // WithProperty add to a schema
 func (s *Schema) WithProperty(name string, required bool, prop *Schema) *Schema {
+	s.addPropertyOrder(name)
 	s.Properties[name] = prop
 	if required {
 		if s.Required == nil {
@@ -158,6 +330,7 @@ func (s *Schema) WithProperty(name string, required bool, prop *Schema) *Schema
 
 // WithBasicProperty add a basic propertity
 func (s *Schema) WithBasicProperty(name, propType, description string, required bool) *Schema {
+	s.addPropertyOrder(name)
 	s.Properties[name] = &Schema{
 		Type:        propType,
 		Description: description,
@@ -171,6 +344,12 @@ func (s *Schema) WithBasicProperty(name, propType, description string, required
 	return s
 }
 
+func (s *Schema) addPropertyOrder(name string) {
+	if _, exists := s.Properties[name]; !exists {
+		s.propertyOrder = append(s.propertyOrder, name)
+	}
+}
+
 // WithRequired make a schema to required: true or false
 func (s *Schema) WithRequired(required bool) *Schema {
 	if s.Required == nil {
@@ -183,13 +362,18 @@ func (s *Schema) WithRequired(required bool) *Schema {
 // WithOneOf set as one of.
 func (s *Schema) WithOneOf(keyAndValues ...interface{}) *Schema {
 	if len(keyAndValues)%2 != 0 {
-		panic("invalid kv pair args")
+		s.fail(errors.New("invalid kv pair args"))
+		return s
 	}
 
 	n := len(keyAndValues) / 2
 	for i := 0; i < n; i++ {
 		k, v := keyAndValues[i*2], keyAndValues[i*2+1]
-		key := k.(string)
+		key, ok := k.(string)
+		if !ok {
+			s.fail(fmt.Errorf("invalid oneOf key:%v", k))
+			continue
+		}
 		var (
 			schema *Schema
 			err    error
@@ -199,7 +383,8 @@ func (s *Schema) WithOneOf(keyAndValues ...interface{}) *Schema {
 		} else {
 			schema, err = Interface(v)
 			if err != nil {
-				panic(err)
+				s.fail(err)
+				continue
 			}
 		}
 		s.OneOf = append(s.OneOf, schema)
@@ -210,20 +395,159 @@ func (s *Schema) WithOneOf(keyAndValues ...interface{}) *Schema {
 // WithAnyOf set as any of
 func (s *Schema) WithAnyOf(args ...interface{}) *Schema {
 	if len(args) == 0 {
-		panic(ErrNoOneOf)
+		s.fail(ErrNoOneOf)
+		return s
 	}
+	parser := &schemaParser{root: s.root}
 	for _, arg := range args {
-		schema, err := Interface(arg)
+		if arg == nil {
+			s.fail(errors.New("nil anyOf value"))
+			continue
+		}
+		// Named struct types are referenced when schema has root
+		schema, err := parser.nested(reflect.TypeOf(arg), reflect.ValueOf(arg))
 		if err != nil {
-			panic(err)
+			s.fail(err)
+			continue
 		}
 		s.AnyOf = append(s.AnyOf, schema)
 	}
 	return s
 }
 
+// fail record err to root with CollectErrors, schema without root always panic
+func (s *Schema) fail(err error) {
+	if s.root == nil {
+		panic(err)
+	}
+	s.root.fail(&BuildError{Err: err})
+}
+
 // WithItems set array items
 func (s *Schema) WithItems(schema *Schema) *Schema {
 	s.Items = schema
 	return s
 }
+
+// WithTitle set title
+func (s *Schema) WithTitle(title string) *Schema {
+	s.Title = title
+	return s
+}
+
+// WithEnum set allowed values
+func (s *Schema) WithEnum(values ...interface{}) *Schema {
+	s.Enum = values
+	return s
+}
+
+// WithDefault set default value
+func (s *Schema) WithDefault(v interface{}) *Schema {
+	s.Default = v
+	return s
+}
+
+// WithExample set example value
+func (s *Schema) WithExample(v interface{}) *Schema {
+	s.Example = v
+	return s
+}
+
+// WithMultipleOf make numbers multiple of v
+func (s *Schema) WithMultipleOf(v interface{}) *Schema {
+	s.MultipleOf = v
+	return s
+}
+
+// WithMinimum set minimum of numbers, the minimum itself is not allowed if exclusive
+func (s *Schema) WithMinimum(min interface{}, exclusive bool) *Schema {
+	s.Minimum = min
+	s.ExclusiveMinimum = exclusive
+	return s
+}
+
+// WithMaximum set maximum of numbers, the maximum itself is not allowed if exclusive
+func (s *Schema) WithMaximum(max interface{}, exclusive bool) *Schema {
+	s.Maximum = max
+	s.ExclusiveMaximum = exclusive
+	return s
+}
+
+// WithLength set min and max length of strings, negative values are ignored
+func (s *Schema) WithLength(min, max int64) *Schema {
+	s.MinLength, s.MaxLength = nonNegative(min), nonNegative(max)
+	return s
+}
+
+// WithItemsRange set min and max number of array items, negative values are ignored
+func (s *Schema) WithItemsRange(min, max int64) *Schema {
+	s.MinItems, s.MaxItems = nonNegative(min), nonNegative(max)
+	return s
+}
+
+// WithUniqueItems make array items unique
+func (s *Schema) WithUniqueItems(unique bool) *Schema {
+	s.UniqueItems = unique
+	return s
+}
+
+// WithPropertiesRange set min and max number of object properties, negative values are ignored
+func (s *Schema) WithPropertiesRange(min, max int64) *Schema {
+	s.MinProperties, s.MaxProperties = nonNegative(min), nonNegative(max)
+	return s
+}
+
+// WithNullable allow null
+func (s *Schema) WithNullable(nullable bool) *Schema {
+	s.Nullable = nullable
+	return s
+}
+
+// WithReadOnly make schema only sent in responses
+func (s *Schema) WithReadOnly(readOnly bool) *Schema {
+	s.ReadOnly = readOnly
+	return s
+}
+
+// WithWriteOnly make schema only sent in requests
+func (s *Schema) WithWriteOnly(writeOnly bool) *Schema {
+	s.WriteOnly = writeOnly
+	return s
+}
+
+// WithDeprecated mark schema deprecated
+func (s *Schema) WithDeprecated(deprecated bool) *Schema {
+	s.Deprecated = deprecated
+	return s
+}
+
+// WithDiscriminator set property telling which schema of oneOf/anyOf a value matches
+func (s *Schema) WithDiscriminator(propertyName string, mapping map[string]string) *Schema {
+	s.Discriminator = &Discriminator{
+		PropertyName: propertyName,
+		Mapping:      mapping,
+	}
+	return s
+}
+
+// WithXML set XML representation
+func (s *Schema) WithXML(xml *XML) *Schema {
+	s.XML = xml
+	return s
+}
+
+// WithExternalDocs add link to external documentation
+func (s *Schema) WithExternalDocs(url, description string) *Schema {
+	s.ExternalDocs = &ExternalDocs{
+		URL:         url,
+		Description: description,
+	}
+	return s
+}
+
+func nonNegative(v int64) *int64 {
+	if v < 0 {
+		return nil
+	}
+	return &v
+}
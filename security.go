@@ -0,0 +1,120 @@
+package openapi
+
+// SecuritySchemeType type of security scheme
+type SecuritySchemeType string
+
+// Valid security scheme types
+const (
+	APIKeySecurity        SecuritySchemeType = "apiKey"
+	HTTPSecurity          SecuritySchemeType = "http"
+	OAuth2Security        SecuritySchemeType = "oauth2"
+	OpenIDConnectSecurity SecuritySchemeType = "openIdConnect"
+)
+
+// SecurityScheme SecuritySchemeObject
+type SecurityScheme struct {
+	Type        SecuritySchemeType `json:"type" validate:"required,oneof=apiKey http oauth2 openIdConnect"`
+	Description string             `json:"description,omitempty"`
+	// Name and In are for apiKey
+	Name string    `json:"name,omitempty"`
+	In   ParamType `json:"in,omitempty"`
+	// Scheme and BearerFormat are for http
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	// Flows is for oauth2
+	Flows *OAuthFlows `json:"flows,omitempty"`
+	// OpenIDConnectURL is for openIdConnect
+	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+}
+
+// OAuthFlows OAuthFlowsObject
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+}
+
+// OAuthFlow OAuthFlowObject, scope name -> description
+type OAuthFlow struct {
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	RefreshURL       string            `json:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes"`
+}
+
+// SecurityRequirement maps names of security schemes to the scopes required.
+// Scopes are only used by oauth2 and openIdConnect
+type SecurityRequirement map[string][]string
+
+// MarshalJSON make sure scopes is always an array
+func (s SecurityRequirement) MarshalJSON() ([]byte, error) {
+	m := make(map[string][]string, len(s))
+	for name, scopes := range s {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		m[name] = scopes
+	}
+	return json.Marshal(m)
+}
+
+// NewAPIKeyScheme create scheme with api key passed in query, header or cookie
+func NewAPIKeyScheme(name string, in ParamType, description string) *SecurityScheme {
+	return &SecurityScheme{
+		Type:        APIKeySecurity,
+		Name:        name,
+		In:          in,
+		Description: description,
+	}
+}
+
+// NewBasicScheme create http basic authentication scheme
+func NewBasicScheme(description string) *SecurityScheme {
+	return &SecurityScheme{
+		Type:        HTTPSecurity,
+		Scheme:      "basic",
+		Description: description,
+	}
+}
+
+// NewBearerScheme create http bearer authentication scheme, bearerFormat is a hint like "JWT"
+func NewBearerScheme(bearerFormat, description string) *SecurityScheme {
+	return &SecurityScheme{
+		Type:         HTTPSecurity,
+		Scheme:       "bearer",
+		BearerFormat: bearerFormat,
+		Description:  description,
+	}
+}
+
+// NewOAuth2Scheme create oauth2 scheme with flows
+func NewOAuth2Scheme(flows *OAuthFlows, description string) *SecurityScheme {
+	return &SecurityScheme{
+		Type:        OAuth2Security,
+		Flows:       flows,
+		Description: description,
+	}
+}
+
+// NewOpenIDConnectScheme create OpenID Connect scheme with discovery url
+func NewOpenIDConnectScheme(url, description string) *SecurityScheme {
+	return &SecurityScheme{
+		Type:             OpenIDConnectSecurity,
+		OpenIDConnectURL: url,
+		Description:      description,
+	}
+}
+
+// AddSecurityScheme add security scheme to global components,
+// key is the name used in security requirements
+func (o *OpenAPI) AddSecurityScheme(key string, scheme *SecurityScheme) string {
+	o.Components.SecuritySchemes[key] = scheme
+	return "#/components/securitySchemes/" + key
+}
+
+// WithSecurity add security requirements for all operations
+func (o *OpenAPI) WithSecurity(reqs ...SecurityRequirement) *OpenAPI {
+	o.Security = append(o.Security, reqs...)
+	return o
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.AddSecurityScheme("apiKey", NewAPIKeyScheme("X-API-Key", HeaderParam, "API key"))
+	o.AddSecurityScheme("bearer", NewBearerScheme("JWT", ""))
+	o.AddSecurityScheme("oauth", NewOAuth2Scheme(&OAuthFlows{
+		AuthorizationCode: &OAuthFlow{
+			AuthorizationURL: "https://example.com/oauth/authorize",
+			TokenURL:         "https://example.com/oauth/token",
+			Scopes: map[string]string{
+				"books:read":  "Read books",
+				"books:write": "Write books",
+			},
+		},
+	}, ""))
+	o.WithSecurity(SecurityRequirement{"apiKey": nil})
+
+	r := NewRouter(o)
+	r.Route("/books", func(r Router) {
+		r.WithSecurity(SecurityRequirement{"oauth": {"books:read"}})
+		r.GET("", "List books", "List books").
+			Returns(200, "Book content", "bookArray", []*Book{})
+		r.Route("/{id}", func(r Router) {
+			r.WithPathParam("id", "ID of the book")
+			r.WithSecurity(SecurityRequirement{"bearer": nil})
+			r.GET("", "Get single book", "Info of a book").
+				Returns(200, "Book content", "book", &Book{})
+		})
+	})
+	r.GET("/health", "Health check", "Health check").
+		NoSecurity().
+		Returns(200, "OK", "", nil)
+
+	books := o.Paths["/books"].operations["get"]
+	if books.Security == nil || len(*books.Security) != 1 || (*books.Security)[0]["oauth"][0] != "books:read" {
+		t.Fatal("operation should inherit security of router")
+	}
+	book := o.Paths["/books/{id}"].operations["get"]
+	if book.Security == nil || len(*book.Security) != 1 || (*book.Security)[0]["oauth"] != nil {
+		t.Fatal("security of sub router should replace security of upstream router")
+	}
+	if _, ok := (*book.Security)[0]["bearer"]; !ok {
+		t.Fatalf("Expect bearer of sub router, got %v", *book.Security)
+	}
+
+	raw, err := o.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(raw)
+	for _, expect := range []string{
+		`"security":[{"apiKey":[]}]`,
+		`"apiKey":{"type":"apiKey","description":"API key","name":"X-API-Key","in":"header"}`,
+		`"bearer":{"type":"http","scheme":"bearer","bearerFormat":"JWT"}`,
+		`"security":[]}}`,
+	} {
+		if !strings.Contains(doc, expect) {
+			t.Errorf("Expect %s in:\n%s", expect, doc)
+		}
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	o.AddSecurityScheme("broken", &SecurityScheme{Type: OAuth2Security, Flows: &OAuthFlows{Password: &OAuthFlow{}}})
+	book.WithSecurity(SecurityRequirement{"bearer": {"admin"}}, SecurityRequirement{"unknown": nil})
+	expect := []string{
+		"/paths/~1books~1{id}/get/security/0/bearer: scopes are only allowed for oauth2 and openIdConnect",
+		"/paths/~1books~1{id}/get/security/1/unknown: no security scheme unknown in components",
+		"/components/securitySchemes/broken/flows/password/tokenUrl: required for password flow",
+		"/components/securitySchemes/broken/flows/password/scopes: failed on rule required",
+	}
+	errs := o.Validate()
+	if len(errs) != len(expect) {
+		t.Fatalf("Expect %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expect[i] {
+			t.Errorf("Expect:\n%s\nGot:\n%s", expect[i], err.Error())
+		}
+	}
+}
@@ -1,18 +1,40 @@
 /*Package openapi provide OpenAPI 3.0 support for Go*/
 package openapi
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
 type pathMap map[string]*Path
 type opMap map[string]*Operation
 
 type respMap map[string]*Response
 
+// pathMethods are the operation keys allowed in a path item object
+var pathMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
 // OpenAPI document structure
 type OpenAPI struct {
+	collectErrors bool
+	buildErrors   BuildErrors
+	// schemaKeys are component keys of struct types added by schema generation
+	schemaKeys map[reflect.Type]string
+	// derivedKeys are struct types keyed by their type name, since no key was given
+	derivedKeys map[reflect.Type]bool
+	// schemaRefs are schemas referring to components, so that components can be renamed
+	schemaRefs map[string][]*Schema
+
 	OpenAPI    string      `json:"openapi"`
 	Info       Info        `json:"info"`
 	Servers    []Server    `json:"servers,omitempty"`
 	Paths      pathMap     `json:"paths"`
 	Components *Components `json:"components,omitempty"`
+	// Security applies to all operations unless overridden by operation
+	Security []SecurityRequirement `json:"security,omitempty"`
 }
 
 // Info of global document
@@ -45,6 +67,12 @@ type Contact struct {
 	Email string `json:"email,omitempty"`
 }
 
+// ExternalDocs reference to external documentation
+type ExternalDocs struct {
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url" validate:"required"`
+}
+
 // License info
 type License struct {
 	Name string `json:"name" validate:"required"`
@@ -61,19 +89,67 @@ type Path struct {
 	Parameters  []*Param `json:"parameters,omitempty"`
 }
 
-// MarshalJSON marshal path
+// MarshalJSON marshal path, operations are in the order of pathMethods
 func (p Path) MarshalJSON() ([]byte, error) {
+	keys := []string{"summary", "description"}
 	m := map[string]interface{}{
 		"summary":     p.Summary,
 		"description": p.Description,
 	}
+	for _, method := range pathMethods {
+		if op, ok := p.operations[method]; ok {
+			keys = append(keys, method)
+			m[method] = op
+		}
+	}
 	if len(p.Parameters) != 0 {
+		keys = append(keys, "parameters")
 		m["parameters"] = p.Parameters
 	}
-	for method, op := range p.operations {
-		m[method] = op
+	return marshalOrdered(keys, func(key string) interface{} {
+		return m[key]
+	})
+}
+
+// UnmarshalJSON unmarshal path, operations are collected by their method keys
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var m map[string]jsoniter.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"summary":     &p.Summary,
+		"description": &p.Description,
+		"parameters":  &p.Parameters,
+	}
+	for key, v := range fields {
+		raw, ok := m[key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, v); err != nil {
+			return fmt.Errorf("failed to parse path field %s:%s", key, err.Error())
+		}
+	}
+	p.operations = make(opMap)
+	for _, method := range pathMethods {
+		raw, ok := m[method]
+		if !ok {
+			continue
+		}
+		op := &Operation{
+			method: method,
+			path:   p,
+		}
+		if err := json.Unmarshal(raw, op); err != nil {
+			return fmt.Errorf("failed to parse operation %s:%s", method, err.Error())
+		}
+		if op.Responses == nil {
+			op.Responses = make(Responses)
+		}
+		p.operations[method] = op
 	}
-	return json.Marshal(m)
+	return nil
 }
 
 // Root document of whole application
@@ -87,6 +163,8 @@ func (p *Path) Root() *OpenAPI {
 // Param ParameterObject
 type Param struct {
 	root *OpenAPI
+	// Ref to a param in components, other fields are ignored when set
+	Ref string `json:"-"`
 	// Fixed fields
 	Name            string    `json:"name" validate:"required"`
 	In              ParamType `json:"in" validate:"required,oneof=query header path cookie"`
@@ -95,9 +173,42 @@ type Param struct {
 	Deprecated      bool      `json:"deprecated,omitempty"`
 	AllowEmptyValue bool      `json:"allowEmptyValue,omitempty"`
 	// Below are optional fields
-	Schema   *Schema             `json:"schema,omitempty"`
-	Example  interface{}         `json:"example,omitempty"`
-	Examples map[string]*Example `json:"examples,omitempty"`
+	Style         ParamStyle          `json:"style,omitempty"`
+	Explode       *bool               `json:"explode,omitempty"`
+	AllowReserved bool                `json:"allowReserved,omitempty"`
+	Schema        *Schema             `json:"schema,omitempty"`
+	Example       interface{}         `json:"example,omitempty"`
+	Examples      map[string]*Example `json:"examples,omitempty"`
+	// Content describes param with a media type instead of schema, it has only one entry
+	Content mediaTypeMap `json:"content,omitempty"`
+}
+
+// MarshalJSON marshal param or its ref
+func (p Param) MarshalJSON() ([]byte, error) {
+	if p.Ref != "" {
+		return marshalRef(p.Ref), nil
+	}
+	type paramAlias Param
+	return json.Marshal(paramAlias(p))
+}
+
+// UnmarshalJSON unmarshal param or its ref
+func (p *Param) UnmarshalJSON(data []byte) error {
+	ref, err := unmarshalRef(data)
+	if err != nil {
+		return err
+	}
+	if ref != "" {
+		*p = Param{Ref: ref}
+		return nil
+	}
+	type paramAlias Param
+	var alias paramAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*p = Param(alias)
+	return nil
 }
 
 // Example ExampleObj
@@ -120,6 +231,27 @@ type MediaType struct {
 	Schema   *Schema             `json:"schema,omitempty"`
 	Example  interface{}         `json:"example,omitempty"`
 	Examples map[string]*Example `json:"examples,omitempty"`
+	// Encoding of properties in multipart and form bodies, keyed by property names
+	Encoding map[string]*Encoding `json:"encoding,omitempty"`
+}
+
+// Encoding describes how a property of multipart or form body is encoded
+type Encoding struct {
+	ContentType   string     `json:"contentType,omitempty"`
+	Headers       paramMap   `json:"headers,omitempty"`
+	Style         ParamStyle `json:"style,omitempty"`
+	Explode       *bool      `json:"explode,omitempty"`
+	AllowReserved bool       `json:"allowReserved,omitempty"`
+}
+
+// WithHeader add header of a multipart part, Content-Type is described by ContentType instead
+func (e *Encoding) WithHeader(name string, header *Param) *Encoding {
+	if e.Headers == nil {
+		e.Headers = make(paramMap)
+	}
+	header.In = HeaderParam
+	e.Headers[name] = header
+	return e
 }
 
 // Responses is actually a map
@@ -129,24 +261,71 @@ type mediaTypeMap map[string]*MediaType
 
 // Response response object
 type Response struct {
+	// Ref to a response in components, other fields are ignored when set
+	Ref         string       `json:"-"`
 	Description string       `json:"description"`
 	Headers     paramMap     `json:"headers,omitempty"`
 	Content     mediaTypeMap `json:"content,omitempty"`
 }
 
+// MarshalJSON marshal response or its ref
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.Ref != "" {
+		return marshalRef(r.Ref), nil
+	}
+	type responseAlias Response
+	return json.Marshal(responseAlias(r))
+}
+
+// UnmarshalJSON unmarshal response or its ref
+func (r *Response) UnmarshalJSON(data []byte) error {
+	ref, err := unmarshalRef(data)
+	if err != nil {
+		return err
+	}
+	if ref != "" {
+		*r = Response{Ref: ref}
+		return nil
+	}
+	type responseAlias Response
+	var alias responseAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*r = Response(alias)
+	return nil
+}
+
+func marshalRef(ref string) []byte {
+	return []byte(`{"$ref":` + strconv.Quote(ref) + `}`)
+}
+
+// unmarshalRef returns the $ref of an object, or empty string if it's not a ref
+func unmarshalRef(data []byte) (string, error) {
+	var obj struct {
+		Ref string `json:"$ref"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", err
+	}
+	return obj.Ref, nil
+}
+
 type schemaMap map[string]*Schema
 type exampleMap map[string]*Example
 type reqBodyMap map[string]*RequestBody
+type securitySchemeMap map[string]*SecurityScheme
 
 // Components object
 type Components struct {
-	Schemas       schemaMap        `json:"schemas,omitempty"`
-	Responses     respMap          `json:"responses,omitempty"`
-	Parameters    paramMap         `json:"parameters,omitempty"`
-	Examples      exampleMap       `json:"examples,omitempty"`
-	RequestBodies reqBodyMap       `json:"requestBodies,omitempty"`
-	Headers       paramMap         `json:"-"`
-	Links         map[string]*Link `json:"links,omitempty"`
+	Schemas         schemaMap         `json:"schemas,omitempty"`
+	Responses       respMap           `json:"responses,omitempty"`
+	Parameters      paramMap          `json:"parameters,omitempty"`
+	Examples        exampleMap        `json:"examples,omitempty"`
+	RequestBodies   reqBodyMap        `json:"requestBodies,omitempty"`
+	Headers         paramMap          `json:"-"`
+	SecuritySchemes securitySchemeMap `json:"securitySchemes,omitempty"`
+	Links           map[string]*Link  `json:"links,omitempty"`
 }
 
 // Link to a resuable object
@@ -178,3 +357,33 @@ func (p ParamType) IsValid() bool {
 		return false
 	}
 }
+
+// ParamStyle describes how values of param are serialized
+type ParamStyle string
+
+// Valid param styles
+const (
+	MatrixStyle         ParamStyle = "matrix"
+	LabelStyle          ParamStyle = "label"
+	FormStyle           ParamStyle = "form"
+	SimpleStyle         ParamStyle = "simple"
+	SpaceDelimitedStyle ParamStyle = "spaceDelimited"
+	PipeDelimitedStyle  ParamStyle = "pipeDelimited"
+	DeepObjectStyle     ParamStyle = "deepObject"
+)
+
+// IsValidFor return whether style can be used by params in location in
+func (s ParamStyle) IsValidFor(in ParamType) bool {
+	switch s {
+	case MatrixStyle, LabelStyle:
+		return in == PathParam
+	case FormStyle:
+		return in == QueryParam || in == CookieParam
+	case SimpleStyle:
+		return in == PathParam || in == HeaderParam
+	case SpaceDelimitedStyle, PipeDelimitedStyle, DeepObjectStyle:
+		return in == QueryParam
+	default:
+		return false
+	}
+}
@@ -2,65 +2,77 @@ package openapi
 
 // Provide utility to convert struct to OpenAPI document
 import (
+	stdjson "encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
-// Interface parse interface as schema
+// Interface parse interface as schema, nested types are inlined
 func Interface(v interface{}) (schema *Schema, err error) {
+	return (&schemaParser{}).schema(v)
+}
+
+// schemaParser converts values to schemas. With document root, named struct types reached through
+// fields, slice elements, map values or pointers are added to components once, and referenced with $ref
+type schemaParser struct {
+	root *OpenAPI
+	// visiting are named types being parsed, to find recursive types without root
+	visiting map[reflect.Type]bool
+}
+
+func (p *schemaParser) schema(v interface{}) (schema *Schema, err error) {
+	if tv := reflect.TypeOf(v); tv != nil {
+		if schema := registeredSchema(tv); schema != nil {
+			return schema, nil
+		}
+	}
 	// Try SchemaDoc provided by itself
 	schemaProvider, ok := v.(SchemaDoc)
 	if ok {
-		schema = schemaProvider.SchemaDoc()
-	} else {
-		var err error
-		schema, err = parseInterface(reflect.TypeOf(v), reflect.ValueOf(v))
-		if err != nil {
-			return nil, err
-		}
+		return schemaProvider.SchemaDoc(), nil
 	}
-	return schema, nil
+	return p.parseInterface(reflect.TypeOf(v), reflect.ValueOf(v))
 }
 
-func parseMap(tv reflect.Type, rv reflect.Value) (*Schema, error) {
-	schema := &Schema{
-		Type:                 "object",
-		AdditionalProperties: &Schema{},
-	}
+func (p *schemaParser) parseMap(tv reflect.Type, rv reflect.Value) (*Schema, error) {
 	elemType := tv.Elem()
-	switch elemType.Kind() {
-	case reflect.Struct, reflect.Ptr:
-		var elemValue reflect.Value
-		if len(rv.MapKeys()) != 0 {
-			elemValue = rv.MapIndex(rv.MapKeys()[0])
-		} else {
-			elemValue = reflect.New(elemType).Elem()
-		}
-		s, err := parseInterface(elemType, elemValue)
-		if err != nil {
-			return nil, fmt.Errorf("failed to process map element %v", rv.Elem())
-		}
-		schema.AdditionalProperties = s
-	default:
-		typ, format := kindToType(elemType.Kind())
-		schema.AdditionalProperties.Type = typ
-		schema.AdditionalProperties.Format = format
+	var elemValue reflect.Value
+	if rv.Len() != 0 {
+		elemValue = rv.MapIndex(rv.MapKeys()[0])
+	} else {
+		elemValue = reflect.New(elemType).Elem()
 	}
-
-	return schema, nil
+	s, err := p.nested(elemType, elemValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process map element of %v:%s", tv, err.Error())
+	}
+	return &Schema{
+		Type:                 "object",
+		AdditionalProperties: s,
+	}, nil
 }
 
 func kindToType(kind reflect.Kind) (typ string, format string) {
 	switch kind {
-	case reflect.Int32:
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
 		return "integer", "int32"
-	case reflect.Int, reflect.Int64:
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return "integer", "int64"
-	case reflect.Float32, reflect.Float64:
-		return "number", ""
+	case reflect.Float32:
+		return "number", "float"
+	case reflect.Float64:
+		return "number", "double"
 	case reflect.String:
 		return "string", ""
 	case reflect.Bool:
@@ -70,7 +82,207 @@ func kindToType(kind reflect.Kind) (typ string, format string) {
 	}
 }
 
-func parseInterface(tv reflect.Type, rv reflect.Value) (schema *Schema, err error) {
+// kindSchema return schema of basic kind, integers are bounded by their size
+func kindSchema(kind reflect.Kind) *Schema {
+	typ, format := kindToType(kind)
+	schema := &Schema{
+		Type:   typ,
+		Format: format,
+	}
+	switch kind {
+	case reflect.Int8:
+		schema.Minimum, schema.Maximum = int64(math.MinInt8), int64(math.MaxInt8)
+	case reflect.Int16:
+		schema.Minimum, schema.Maximum = int64(math.MinInt16), int64(math.MaxInt16)
+	case reflect.Uint8:
+		schema.Minimum, schema.Maximum = int64(0), int64(math.MaxUint8)
+	case reflect.Uint16:
+		schema.Minimum, schema.Maximum = int64(0), int64(math.MaxUint16)
+	case reflect.Uint32:
+		schema.Minimum, schema.Maximum = int64(0), int64(math.MaxUint32)
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		schema.Minimum = int64(0)
+	}
+	return schema
+}
+
+var (
+	timeType               = reflect.TypeOf(time.Time{})
+	jsonNumberType         = reflect.TypeOf(stdjson.Number(""))
+	jsonRawMessageType     = reflect.TypeOf(stdjson.RawMessage{})
+	jsoniterNumberType     = reflect.TypeOf(jsoniter.Number(""))
+	jsoniterRawMessageType = reflect.TypeOf(jsoniter.RawMessage{})
+	fileHeaderType         = reflect.TypeOf(multipart.FileHeader{})
+	readerType             = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// binarySchema is schema of files in multipart bodies
+func binarySchema() *Schema {
+	return &Schema{
+		Type:   "string",
+		Format: "binary",
+	}
+}
+
+// typeSchema return schema of registered types, and types that are encoded in a fixed way,
+// like numbers, []byte and time.Time. It returns nil for others
+func typeSchema(tv reflect.Type) *Schema {
+	if schema := registeredSchema(tv); schema != nil {
+		return schema
+	}
+	return builtinSchema(tv)
+}
+
+// builtinSchema return schema of types that are encoded in a fixed way, nil for others
+func builtinSchema(tv reflect.Type) *Schema {
+	switch tv {
+	case timeType:
+		return &Schema{
+			Type:   "string",
+			Format: "date-time",
+		}
+	case jsonNumberType, jsoniterNumberType:
+		return &Schema{
+			Type: "number",
+		}
+	case jsonRawMessageType, jsoniterRawMessageType:
+		// Any JSON value
+		return &Schema{}
+	case fileHeaderType, reflect.PtrTo(fileHeaderType):
+		return binarySchema()
+	}
+	switch kind := tv.Kind(); kind {
+	case reflect.Interface:
+		// Readers are uploaded files
+		if tv.Implements(readerType) {
+			return binarySchema()
+		}
+		return &Schema{}
+	case reflect.Slice:
+		// []byte is encoded as base64 string
+		if tv.Elem().Kind() == reflect.Uint8 {
+			return &Schema{
+				Type:   "string",
+				Format: "byte",
+			}
+		}
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return kindSchema(kind)
+	}
+	return nil
+}
+
+// querySchema return schema of query param example, complex types are not supported
+func querySchema(tv reflect.Type) *Schema {
+	if schema := typeSchema(tv); schema != nil {
+		return schema
+	}
+	typ, _ := kindToType(tv.Kind())
+	return &Schema{
+		Type: typ,
+	}
+}
+
+// nested parse type reached through field, slice element, map value or pointer.
+// Named struct types are referenced when parser has root
+func (p *schemaParser) nested(tv reflect.Type, rv reflect.Value) (*Schema, error) {
+	if st := namedStruct(tv); st != nil && p.root != nil {
+		return p.ref(st, tv, rv)
+	}
+	// Without a component to refer to, a recursive type never ends
+	st := tv
+	for st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if st.Name() != "" {
+		if p.visiting[st] {
+			return nil, fmt.Errorf("%s:%s", ErrRecursiveType.Error(), st.String())
+		}
+		if p.visiting == nil {
+			p.visiting = make(map[reflect.Type]bool)
+		}
+		p.visiting[st] = true
+		defer delete(p.visiting, st)
+	}
+	return p.parseInterface(tv, rv)
+}
+
+// ref add struct type st to components once, the key is reserved before parsing,
+// so that recursive values of st refer to the component being built
+func (p *schemaParser) ref(st, tv reflect.Type, rv reflect.Value) (*Schema, error) {
+	if key, ok := p.root.schemaKeys[st]; ok {
+		if ref := p.root.GetSchema(key); ref != nil {
+			return ref, nil
+		}
+	}
+	key := uniqueKey(typeKey(st), func(key string) bool {
+		_, exists := p.root.Components.Schemas[key]
+		return exists
+	})
+	p.root.reserveSchema(st, key, true)
+	schema, err := p.parseInterface(tv, rv)
+	if err != nil {
+		p.root.releaseSchema(st, key)
+		return nil, err
+	}
+	return p.root.AddSchema(key, schema), nil
+}
+
+// parseImplementations describe registered implementations with oneOf and discriminator.
+// Implementations are referenced from components when parser has root, the mapping is only set in that case
+func (p *schemaParser) parseImplementations(impls *implementations) (*Schema, error) {
+	values := make([]string, 0, len(impls.types))
+	for value := range impls.types {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	schema := &Schema{
+		Discriminator: &Discriminator{
+			PropertyName: impls.propertyName,
+		},
+	}
+	for _, value := range values {
+		tv := impls.types[value]
+		s, err := p.nested(tv, reflect.New(tv).Elem())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing implementation %s:%s", value, err.Error())
+		}
+		schema.OneOf = append(schema.OneOf, s)
+		if s.Ref != "" {
+			if schema.Discriminator.Mapping == nil {
+				schema.Discriminator.Mapping = make(map[string]string)
+			}
+			schema.Discriminator.Mapping[value] = s.Ref
+			p.root.referSchema(s.key, schema)
+		}
+	}
+	return schema, nil
+}
+
+// namedStruct return named struct type of tv or pointers to it, nil otherwise
+func namedStruct(tv reflect.Type) reflect.Type {
+	if tv == nil {
+		return nil
+	}
+	for tv.Kind() == reflect.Ptr {
+		tv = tv.Elem()
+	}
+	if tv.Kind() != reflect.Struct || tv.Name() == "" || typeSchema(tv) != nil {
+		return nil
+	}
+	return tv
+}
+
+func (p *schemaParser) parseInterface(tv reflect.Type, rv reflect.Value) (schema *Schema, err error) {
+	if impls := registeredImplementations(tv); impls != nil {
+		return p.parseImplementations(impls)
+	}
+	if schema := registeredSchema(tv); schema != nil {
+		return schema, nil
+	}
+	// SchemaDoc goes before the built-in mapping, so named types like enums of strings can describe themselves
 	method := rv.MethodByName("SchemaDoc")
 	if method.IsValid() {
 		values := method.Call(nil)
@@ -84,18 +296,21 @@ func parseInterface(tv reflect.Type, rv reflect.Value) (schema *Schema, err erro
 		}
 		return s, nil
 	}
+	if schema := builtinSchema(tv); schema != nil {
+		return schema, nil
+	}
 	switch tv.Kind() {
 	case reflect.Struct:
-		return parseStruct(tv, rv)
-	case reflect.Slice:
+		return p.parseStruct(tv, rv)
+	case reflect.Slice, reflect.Array:
 		elemType := tv.Elem()
 		var elemValue reflect.Value
-		if !rv.IsNil() && rv.Len() != 0 {
+		if (tv.Kind() == reflect.Array || !rv.IsNil()) && rv.Len() != 0 {
 			elemValue = rv.Index(0)
 		} else {
 			elemValue = reflect.New(tv.Elem()).Elem()
 		}
-		schema, err := parseInterface(elemType, elemValue)
+		schema, err := p.nested(elemType, elemValue)
 		if err != nil {
 			return nil, err
 		}
@@ -104,7 +319,7 @@ func parseInterface(tv reflect.Type, rv reflect.Value) (schema *Schema, err erro
 			Items: schema,
 		}, nil
 	case reflect.Map:
-		return parseMap(tv, rv)
+		return p.parseMap(tv, rv)
 	case reflect.Ptr:
 		for tv.Kind() == reflect.Ptr {
 			tv = tv.Elem()
@@ -114,64 +329,369 @@ func parseInterface(tv reflect.Type, rv reflect.Value) (schema *Schema, err erro
 				rv = reflect.New(tv).Elem()
 			}
 		}
-		return parseInterface(tv, rv)
+		return p.parseInterface(tv, rv)
 	default:
-		typ, format := kindToType(tv.Kind())
-		return &Schema{
-			Type:   typ,
-			Format: format,
-		}, nil
+		return kindSchema(tv.Kind()), nil
 	}
 }
 
-func parseStruct(tv reflect.Type, rv reflect.Value) (schema *Schema, err error) {
+func (p *schemaParser) parseStruct(tv reflect.Type, rv reflect.Value) (schema *Schema, err error) {
 	schema = &Schema{
-		Type:       "object",
-		Properties: make(map[string]*Schema),
+		Type:        "object",
+		Description: comment(tv.PkgPath(), tv.Name(), ""),
+		Properties:  make(map[string]*Schema),
 	}
-	for i := 0; i < tv.NumField(); i++ {
-		f := tv.Field(i)
-		var v reflect.Value
-		if rv.Kind() == reflect.Ptr {
-			v = reflect.New(f.Type).Elem()
+	for _, f := range jsonFields(tv) {
+		s, err := p.nested(f.Type, fieldValue(rv, f.index, f.Type))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing field %s:%s", f.Name, err.Error())
+		}
+		if f.quoted {
+			s = &Schema{
+				Type: "string",
+			}
+		}
+
+		s, required, err := describeField(s, f.owner, f.StructField)
+		if err != nil {
+			return nil, err
+		}
+		// Fields without omitempty are always encoded so they are required, nil ones as null
+		if !f.omitEmpty && isNilable(f.Type) && !f.quoted {
+			s = nullable(s)
+		}
+		schema.WithProperty(f.name, required || !f.omitEmpty, s)
+	}
+	return schema, nil
+}
+
+// isNilable tells whether values of type tv can be nil, which encoding/json encodes as null
+func isNilable(tv reflect.Type) bool {
+	switch tv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// nullable allows null for schema s, a ref is wrapped since its siblings are ignored
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{
+			AllOf:    []*Schema{s},
+			Nullable: true,
+		}
+	}
+	s.Nullable = true
+	return s
+}
+
+// describeField apply doc comment and tags of field sf declared by struct type owner to schema s of the field.
+// It returns the schema, which wraps s if s is a ref, and whether validate tag requires the field
+func describeField(s *Schema, owner reflect.Type, sf reflect.StructField) (*Schema, bool, error) {
+	// Doc comment describes the field, unless description tag does
+	description := comment(owner.PkgPath(), owner.Name(), sf.Name)
+	// Siblings of $ref are ignored, so wrap the ref when tags describe or constrain the field
+	vTag, hasValidate := sf.Tag.Lookup("validate")
+	wrapped := s.Ref != "" && (hasCustomTags(sf.Tag) || description != "" || hasValidate)
+	if wrapped {
+		s = &Schema{
+			AllOf: []*Schema{s},
+		}
+	}
+	if description != "" {
+		s.Description = description
+	}
+	// Parse custom tags
+	if err := parseCustomTags(sf.Tag, s); err != nil {
+		return nil, false, err
+	}
+	var required bool
+	if hasValidate {
+		var err error
+		if required, err = parseValidateTag(vTag, s); err != nil {
+			return nil, false, err
+		}
+	}
+	// Wrapper is not needed if validate tag only requires the field
+	if wrapped && reflect.DeepEqual(s, &Schema{AllOf: s.AllOf}) {
+		s = s.AllOf[0]
+	}
+	return s, required, nil
+}
+
+// paramLocations are tags naming fields as params
+var paramLocations = []ParamType{PathParam, QueryParam, HeaderParam, CookieParam}
+
+// paramName return name of field sf as param in location. It's the tag named after the location,
+// or json name of fields without tags of other locations. An empty name means no name is given
+func paramName(sf reflect.StructField, in ParamType) (string, bool) {
+	if name, ok := sf.Tag.Lookup(string(in)); ok {
+		return name, name != "-"
+	}
+	for _, other := range paramLocations {
+		if _, ok := sf.Tag.Lookup(string(other)); ok {
+			return "", false
+		}
+	}
+	// Body fields are decoded by Bind, they are not params
+	if _, ok := sf.Tag.Lookup("body"); ok {
+		return "", false
+	}
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	return name, name != "-"
+}
+
+// params create a param in location for each field of struct v. Names are read from tags named after the location,
+// like query:"name", or json tags, or names of fields. Fields of embedded structs are expanded the same way
+func (p *schemaParser) params(in ParamType, v interface{}) ([]*Param, error) {
+	tv, rv := reflect.TypeOf(v), reflect.ValueOf(v)
+	for tv != nil && tv.Kind() == reflect.Ptr {
+		tv = tv.Elem()
+		if rv.IsNil() {
+			rv = reflect.New(tv).Elem()
 		} else {
-			v = rv.Field(i)
+			rv = rv.Elem()
+		}
+	}
+	if tv == nil || tv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expect struct for %s params, got %v", in, tv)
+	}
+	var params []*Param
+	for i := 0; i < tv.NumField(); i++ {
+		sf := tv.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
 		}
-		jsonTag, ok := f.Tag.Lookup("json")
+		name, ok := paramName(sf, in)
 		if !ok {
 			continue
 		}
-		if jsonTag == "-" {
+		fv := fieldValue(rv, sf.Index, sf.Type)
+		if sf.Anonymous && name == "" && namedStruct(sf.Type) != nil {
+			embedded, err := p.params(in, fv.Interface())
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, embedded...)
 			continue
 		}
-		s, err := parseInterface(f.Type, v)
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		s, err := p.nested(sf.Type, fv)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing field %s", f.Name)
+			return nil, fmt.Errorf("error parsing field %s:%s", sf.Name, err.Error())
 		}
-		// Embeded tag
-		if jsonTag == "," || jsonTag == ",inline" {
-			schema.AllOf = append(schema.AllOf, s)
-			continue
+		s, required, err := describeField(s, tv, sf)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing field %s:%s", sf.Name, err.Error())
 		}
-		p := strings.Split(jsonTag, ",")
-		propName := p[0]
+		param := &Param{
+			root:        p.root,
+			Name:        name,
+			In:          in,
+			Description: s.Description,
+			Required:    required || in == PathParam,
+			Deprecated:  s.Deprecated,
+			Schema:      s,
+		}
+		s.Description, s.Deprecated = "", false
+		if fv.CanInterface() && !reflect.DeepEqual(fv.Interface(), reflect.Zero(sf.Type).Interface()) {
+			param.Example = fv.Interface()
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
 
-		// Parse custom tags
-		if err := parseCustomTags(f.Tag, s); err != nil {
-			return nil, err
+// jsonField is a field encoded by encoding/json, fields promoted from embedded structs included
+type jsonField struct {
+	reflect.StructField
+	// owner is the struct type declaring the field
+	owner     reflect.Type
+	name      string
+	tagged    bool
+	index     []int
+	omitEmpty bool
+	quoted    bool
+}
+
+// jsonFields return fields of struct type tv that encoding/json encodes, in the same order and with the same names.
+// Fields of embedded structs without name are promoted, and conflicting names are dropped
+func jsonFields(tv reflect.Type) []jsonField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []jsonField
+	visited := make(map[reflect.Type]bool)
+	next := []embedded{{typ: tv}}
+	for len(next) != 0 {
+		current := next
+		next = nil
+		// Types embedded at a shallower depth are done, but types embedded twice at the same depth conflict
+		levelTypes := make(map[reflect.Type]bool)
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			levelTypes[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				unexported := sf.PkgPath != ""
+				if unexported && (!sf.Anonymous || ft.Kind() != reflect.Struct) {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if idx := strings.Index(tag, ","); idx >= 0 {
+					name, opts = tag[:idx], tag[idx:]
+				}
+				if !isValidJSONName(name) {
+					name = ""
+				}
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+				f := jsonField{
+					StructField: sf,
+					owner:       e.typ,
+					name:        name,
+					tagged:      name != "",
+					index:       index,
+					omitEmpty:   strings.Contains(opts+",", ",omitempty,"),
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				if strings.Contains(opts+",", ",string,") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+						f.quoted = true
+					}
+				}
+				fields = append(fields, f)
+			}
 		}
-		// Required is special
-		vTag, ok := f.Tag.Lookup("validate")
-		var required bool
-		if ok {
-			required, err = parseValidateTag(vTag, s)
-			if err != nil {
-				return nil, err
+		for typ := range levelTypes {
+			visited[typ] = true
+		}
+	}
+	return dominantFields(fields)
+}
+
+// dominantFields keep the field encoding/json uses for each name: the shallowest one,
+// or the only tagged one among the shallowest. Names with no such field are dropped
+func dominantFields(fields []jsonField) []jsonField {
+	byName := make(map[string][]int)
+	for i, f := range fields {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	var out []jsonField
+	for i, f := range fields {
+		if dominantField(fields, byName[f.name]) == i {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].index, out[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
 			}
 		}
-		schema.WithProperty(propName, required, s)
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// dominantField return index of the dominant field among fields of the same name, or -1 if there isn't one
+func dominantField(fields []jsonField, group []int) int {
+	// Fields are collected by depth, so the first is the shallowest
+	depth := len(fields[group[0]].index)
+	dominant := -1
+	var shallowest, tagged int
+	for _, i := range group {
+		if len(fields[i].index) > depth {
+			break
+		}
+		shallowest++
+		if fields[i].tagged {
+			tagged++
+			dominant = i
+		}
 	}
-	return schema, nil
+	if shallowest == 1 {
+		return group[0]
+	}
+	if tagged == 1 {
+		return dominant
+	}
+	return -1
+}
+
+// isValidJSONName report whether name from json tag is used by encoding/json
+func isValidJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case unicode.IsLetter(c), unicode.IsDigit(c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValue return value of field at index of struct value rv, or zero value of ft when it can't be reached
+func fieldValue(rv reflect.Value, index []int, ft reflect.Type) reflect.Value {
+	for _, i := range index {
+		for rv.IsValid() && rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+		if !rv.IsValid() || rv.Kind() != reflect.Struct {
+			return reflect.New(ft).Elem()
+		}
+		rv = rv.Field(i)
+	}
+	// Values of unexported embedded structs can't be used
+	if !rv.CanInterface() {
+		return reflect.New(ft).Elem()
+	}
+	return rv
+}
+
+var customTags = []string{"title", "description", "format", "pattern", "enum", "default", "example",
+	"multipleOf", "nullable", "readOnly", "writeOnly", "deprecated"}
+
+func hasCustomTags(tag reflect.StructTag) bool {
+	for _, key := range customTags {
+		if _, ok := tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func parseCustomTags(tag reflect.StructTag, schema *Schema) error {
@@ -189,9 +709,9 @@ func parseCustomTags(tag reflect.StructTag, schema *Schema) error {
 			return nil
 		},
 		"enum": func(v string, schema *Schema) error {
-			enums := strings.Split(v, "|")
-			if len(enums) == 0 {
-				return errors.New("no enum values")
+			enums, err := enumValues(schema.Type, strings.Split(v, "|"))
+			if err != nil {
+				return fmt.Errorf("error with enum values:%s", err.Error())
 			}
 			schema.Enum = enums
 			return nil
@@ -204,6 +724,33 @@ func parseCustomTags(tag reflect.StructTag, schema *Schema) error {
 			schema.Default = defaultValue
 			return nil
 		},
+		"title": func(v string, schema *Schema) error {
+			schema.Title = v
+			return nil
+		},
+		"example": func(v string, schema *Schema) error {
+			example, err := tagToExample(schema.Type, v)
+			if err != nil {
+				return fmt.Errorf("error with example value:%s", err.Error())
+			}
+			schema.Example = example
+			return nil
+		},
+		"multipleOf": func(v string, schema *Schema) error {
+			if schema.Type != "integer" && schema.Type != "number" {
+				return fmt.Errorf("multipleOf for type %s", schema.Type)
+			}
+			multipleOf, err := tagToValue(schema.Type, v)
+			if err != nil {
+				return fmt.Errorf("error with multipleOf value:%s", err.Error())
+			}
+			schema.MultipleOf = multipleOf
+			return nil
+		},
+		"nullable":   boolTagParser(func(schema *Schema) *bool { return &schema.Nullable }),
+		"readOnly":   boolTagParser(func(schema *Schema) *bool { return &schema.ReadOnly }),
+		"writeOnly":  boolTagParser(func(schema *Schema) *bool { return &schema.WriteOnly }),
+		"deprecated": boolTagParser(func(schema *Schema) *bool { return &schema.Deprecated }),
 	}
 	for key, fn := range tagParsers {
 		v, ok := tag.Lookup(key)
@@ -217,6 +764,53 @@ func parseCustomTags(tag reflect.StructTag, schema *Schema) error {
 	return nil
 }
 
+// boolTagParser parse tag of a boolean schema field
+func boolTagParser(field func(schema *Schema) *bool) func(v string, schema *Schema) error {
+	return func(v string, schema *Schema) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as boolean:%s", v, err.Error())
+		}
+		*field(schema) = b
+		return nil
+	}
+}
+
+// enumValues convert enum values of tags to values of schema type, values of other types are kept as strings
+func enumValues(schemaType string, values []string) ([]interface{}, error) {
+	if len(values) == 0 {
+		return nil, errors.New("no enum values")
+	}
+	enums := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		switch schemaType {
+		case "integer", "number", "boolean":
+			value, err := tagToValue(schemaType, v)
+			if err != nil {
+				return nil, err
+			}
+			enums = append(enums, value)
+		default:
+			enums = append(enums, v)
+		}
+	}
+	return enums, nil
+}
+
+// tagToExample convert example of tag to value, examples of objects and arrays are written in JSON
+func tagToExample(schemaType string, v string) (interface{}, error) {
+	switch schemaType {
+	case "integer", "number", "boolean", "string":
+		return tagToValue(schemaType, v)
+	default:
+		var example interface{}
+		if err := json.Unmarshal([]byte(v), &example); err != nil {
+			return nil, fmt.Errorf("failed to parse example %s as JSON:%s", v, err.Error())
+		}
+		return example, nil
+	}
+}
+
 // convert string tag value to corresponding value
 func tagToValue(schemaType string, v string) (interface{}, error) {
 	switch schemaType {
@@ -248,69 +842,179 @@ func tagToValue(schemaType string, v string) (interface{}, error) {
 	}
 }
 
-// parse tags from golang validator
+// validateFormats are validator tags that correspond to schema formats
+var validateFormats = map[string]string{
+	"email": "email",
+	"url":   "uri",
+	"uri":   "uri",
+	"uuid":  "uuid",
+	"uuid3": "uuid",
+	"uuid4": "uuid",
+	"uuid5": "uuid",
+	"ipv4":  "ipv4",
+	"ipv6":  "ipv6",
+}
+
+// parse tags from golang validator. Tags after dive apply to items of arrays or values of maps
 func parseValidateTag(vTag string, schema *Schema) (required bool, err error) {
 	if vTag == "" || vTag == "-" {
 		return false, nil
 	}
 	parts := strings.Split(vTag, ",")
-	for _, p := range parts {
-		if p == "required" {
-			required = true
-			continue
-		}
-
-		var v string
-		var isMax bool
-		if strings.HasPrefix(p, "max=") {
-			isMax = true
-			v = strings.TrimPrefix(p, "max=")
-		} else if strings.HasPrefix(p, "min=") {
-			v = strings.TrimPrefix(p, "min=")
-		} else {
-			continue
+	for i, p := range parts {
+		name, v := p, ""
+		if idx := strings.Index(p, "="); idx >= 0 {
+			name, v = p[:idx], p[idx+1:]
 		}
-
-		switch schema.Type {
-		case "string":
-			value, err := strconv.ParseInt(v, 10, 64)
-			if err != nil {
-				return false, fmt.Errorf("failed to parse tag of value %s:%s", v, err.Error())
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			return required, parseDiveTag(strings.Join(parts[i+1:], ","), schema)
+		case "oneof":
+			if schema.Enum, err = enumValues(boundType(schema), splitOneOf(v)); err != nil {
+				return false, fmt.Errorf("failed to parse oneof tag of value %s:%s", v, err.Error())
 			}
-
-			if isMax {
-				schema.MaxLength = &value
-			} else {
-				schema.MinLength = &value
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			if err := parseBoundTag(name, v, schema); err != nil {
+				return false, err
 			}
-
-		case "integer":
-			value, err := strconv.ParseInt(v, 10, 64)
-			if err != nil {
-				return false, fmt.Errorf("failed to parse tag of value %s:%s", v, err.Error())
+		case "unique":
+			if schema.Type == "array" {
+				schema.UniqueItems = true
 			}
-
-			if isMax {
-				schema.Maximum = value
+		case "datetime":
+			if v == "2006-01-02" {
+				schema.Format = "date"
 			} else {
-				schema.Minimum = value
+				schema.Format = "date-time"
 			}
-
-		case "number":
-			value, err := strconv.ParseFloat(v, 64)
-			if err != nil {
-				return false, fmt.Errorf("failed to parse max tag of value %s:%s", v, err.Error())
+		default:
+			if format, ok := validateFormats[name]; ok {
+				schema.Format = format
 			}
+		}
+	}
+	return required, nil
+}
 
-			if isMax {
-				schema.Maximum = value
-			} else {
-				schema.Minimum = value
+// parseDiveTag apply tags to items of array or values of map schema
+func parseDiveTag(vTag string, schema *Schema) error {
+	var elem **Schema
+	switch {
+	case schema.Type == "array" && schema.Items != nil:
+		elem = &schema.Items
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		elem = &schema.AdditionalProperties
+	default:
+		return fmt.Errorf("dive on type %s", schema.Type)
+	}
+	if (*elem).Ref == "" {
+		_, err := parseValidateTag(vTag, *elem)
+		return err
+	}
+	// Siblings of $ref are ignored, so wrap the ref when tags describe the element
+	wrapped := &Schema{}
+	if _, err := parseValidateTag(vTag, wrapped); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(wrapped, &Schema{}) {
+		wrapped.AllOf = []*Schema{*elem}
+		*elem = wrapped
+	}
+	return nil
+}
+
+// splitOneOf split values of oneof tag, values with spaces are quoted like 'a b'
+func splitOneOf(v string) []string {
+	var values []string
+	for v = strings.TrimSpace(v); v != ""; v = strings.TrimSpace(v) {
+		if v[0] == '\'' {
+			if end := strings.IndexByte(v[1:], '\''); end >= 0 {
+				values = append(values, v[1:end+1])
+				v = v[end+2:]
+				continue
 			}
+		}
+		end := strings.IndexByte(v, ' ')
+		if end < 0 {
+			end = len(v)
+		}
+		values = append(values, v[:end])
+		v = v[end:]
+	}
+	return values
+}
 
-		default:
-			return false, fmt.Errorf("unknown max value %s for type %s", v, schema.Type)
+// parseBoundTag set bounds from min, max, len, gt, gte, lt and lte tags.
+// They limit length of strings, number of items of arrays and properties of maps, and value of numbers
+func parseBoundTag(name, v string, schema *Schema) error {
+	switch typ := boundType(schema); typ {
+	case "string", "array", "object":
+		value, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s tag of value %s:%s", name, v, err.Error())
+		}
+		min, max := &schema.MinLength, &schema.MaxLength
+		if typ == "array" {
+			min, max = &schema.MinItems, &schema.MaxItems
+		} else if typ == "object" {
+			min, max = &schema.MinProperties, &schema.MaxProperties
+		}
+		setCountBound(name, value, min, max)
+	case "integer", "number":
+		var value interface{}
+		var err error
+		if schema.Type == "integer" {
+			value, err = strconv.ParseInt(v, 10, 64)
+		} else {
+			value, err = strconv.ParseFloat(v, 64)
 		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s tag of value %s:%s", name, v, err.Error())
+		}
+		switch name {
+		case "min", "gte":
+			schema.Minimum, schema.ExclusiveMinimum = value, false
+		case "gt":
+			schema.Minimum, schema.ExclusiveMinimum = value, true
+		case "max", "lte":
+			schema.Maximum, schema.ExclusiveMaximum = value, false
+		case "lt":
+			schema.Maximum, schema.ExclusiveMaximum = value, true
+		case "len":
+			schema.Minimum, schema.Maximum = value, value
+			schema.ExclusiveMinimum, schema.ExclusiveMaximum = false, false
+		}
+	default:
+		return fmt.Errorf("unknown %s value %s for type %s", name, v, typ)
+	}
+	return nil
+}
+
+// boundType return type of values constrained by schema. Wrappers of refs constrain objects,
+// since refs are only generated for structs
+func boundType(schema *Schema) string {
+	if schema.Type == "" && len(schema.AllOf) == 1 && schema.AllOf[0].Ref != "" {
+		return "object"
+	}
+	return schema.Type
+}
+
+// setCountBound set bounds of lengths or counts, exclusive bounds are converted to inclusive ones
+func setCountBound(name string, value int64, min, max **int64) {
+	switch name {
+	case "min", "gte":
+		*min = &value
+	case "gt":
+		value++
+		*min = &value
+	case "max", "lte":
+		*max = &value
+	case "lt":
+		value--
+		*max = &value
+	case "len":
+		*min, *max = &value, &value
 	}
-	return required, nil
 }
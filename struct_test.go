@@ -1,7 +1,12 @@
 package openapi
 
 import (
+	"database/sql"
+	stdjson "encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // person
@@ -21,7 +26,8 @@ func TestStructTags(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(schema.Required.Properties) != 1 || schema.Required.Properties[0] != "name" {
+	// Fields without omitempty are always encoded
+	if strings.Join(schema.Required.Properties, ",") != "name,age,height,labels" {
 		t.Fatal(schema.Required.Properties)
 	}
 	nameProp, ok := schema.Properties["name"]
@@ -51,7 +57,8 @@ func TestPtr(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(schema.Required.Properties) != 1 || schema.Required.Properties[0] != "name" {
+	// Fields without omitempty are always encoded
+	if strings.Join(schema.Required.Properties, ",") != "name,age,height,labels" {
 		t.Fatal(schema.Required.Properties)
 	}
 	nameProp, ok := schema.Properties["name"]
@@ -219,3 +226,655 @@ func TestEmbedStruct(t *testing.T) {
 	}
 	t.Log(string(raw))
 }
+
+type shelf struct {
+	Featured *Book           `json:"featured" description:"Book of the week"`
+	Latest   Book            `json:"latest"`
+	Books    []Book          `json:"books"`
+	ByName   map[string]Book `json:"byName"`
+	Owner    person          `json:"owner"`
+	Location struct {
+		Room string `json:"room"`
+	} `json:"location"`
+}
+
+func TestNestedRef(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("book", &Book{})
+	o.MustGetSchema("shelf", &shelf{})
+
+	if len(o.Components.Schemas) != 3 {
+		t.Fatalf("Expect book, shelf and person in components, got %v", sortedSchemaKeys(o.Components.Schemas))
+	}
+	s := o.Components.Schemas["shelf"]
+	bookRef := schemaRefPrefix + "book"
+	featured := s.Properties["featured"]
+	if featured.Description != "Book of the week" || len(featured.AllOf) != 1 || featured.AllOf[0].Ref != bookRef {
+		t.Fatalf("Expect featured described and referring book, got %+v", featured)
+	}
+	if s.Properties["latest"].Ref != bookRef ||
+		s.Properties["books"].Items.Ref != bookRef ||
+		s.Properties["byName"].AdditionalProperties.Ref != bookRef {
+		t.Fatal("Expect book referred by field, element and map value")
+	}
+	if s.Properties["owner"].Ref != schemaRefPrefix+"go-openapi.person" {
+		t.Fatalf("Expect person added with type key, got %s", s.Properties["owner"].Ref)
+	}
+	if s.Properties["location"].Properties["room"] == nil {
+		t.Fatal("Expect anonymous struct inlined")
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestNestedRegistered(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("books", []Book{})
+	// Book is registered with type key as element of books, it's renamed to the given key instead of added again
+	ref := o.MustGetSchema("book", &Book{})
+	if ref.Ref != schemaRefPrefix+"book" || o.Components.Schemas["books"].Items.Ref != schemaRefPrefix+"book" {
+		t.Fatalf("Expect Book renamed to book, got %s", ref.Ref)
+	}
+	if keys := sortedSchemaKeys(o.Components.Schemas); strings.Join(keys, ",") != "book,books" {
+		t.Fatalf("Expect one component of Book, got %v", keys)
+	}
+	// Another given key refers to the component
+	ref = o.MustGetSchema("novel", Book{})
+	if ref.Ref != schemaRefPrefix+"novel" || o.Components.Schemas["novel"].Ref != schemaRefPrefix+"book" {
+		t.Fatalf("Expect novel refer to book, got %+v", o.Components.Schemas["novel"])
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
+
+type treeNode struct {
+	Name     string      `json:"name"`
+	Parent   *treeNode   `json:"parent"`
+	Children []*treeNode `json:"children"`
+}
+
+type employee struct {
+	Name       string      `json:"name"`
+	Department *department `json:"department"`
+}
+
+type department struct {
+	Manager employee   `json:"manager"`
+	Staff   []employee `json:"staff"`
+}
+
+type labelTree map[string]labelTree
+
+func TestRecursiveType(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("node", &treeNode{})
+	node := o.Components.Schemas["node"]
+	// Nil parent is encoded as null, so the ref is wrapped
+	if node.Properties["parent"].AllOf[0].Ref != schemaRefPrefix+"node" ||
+		node.Properties["children"].Items.Ref != schemaRefPrefix+"node" {
+		t.Fatal("Expect recursive fields refer to node")
+	}
+
+	o.MustGetSchema("", employee{})
+	if len(o.Components.Schemas) != 3 {
+		t.Fatalf("Expect node, employee and department, got %v", sortedSchemaKeys(o.Components.Schemas))
+	}
+	employeeRef := schemaRefPrefix + "go-openapi.employee"
+	dept := o.Components.Schemas["go-openapi.department"]
+	if dept.Properties["manager"].Ref != employeeRef || dept.Properties["staff"].Items.Ref != employeeRef {
+		t.Fatal("Expect department refer back to employee")
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if _, err := o.JSON(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Components are not changed by failures
+	o.CollectErrors()
+	o.MustGetSchema("labels", labelTree{})
+	if o.Err() == nil || len(o.Components.Schemas) != 3 {
+		t.Fatalf("Expect error of recursive map, got %v", o.Err())
+	}
+}
+
+func TestRecursiveTypeWithoutRoot(t *testing.T) {
+	if _, err := Interface(&treeNode{}); err == nil {
+		t.Fatal("Expect error for recursive type without document")
+	}
+}
+
+func TestTypeCoverage(t *testing.T) {
+	var v struct {
+		Int8     int8               `json:"int8"`
+		Uint8    uint8              `json:"uint8"`
+		Uint16   uint16             `json:"uint16"`
+		Uint64   uint64             `json:"uint64"`
+		Float32  float32            `json:"float32"`
+		Float64  float64            `json:"float64"`
+		Bytes    []byte             `json:"bytes"`
+		Time     time.Time          `json:"time"`
+		TimePtr  *time.Time         `json:"timePtr"`
+		Duration time.Duration      `json:"duration"`
+		Number   stdjson.Number     `json:"number"`
+		Raw      stdjson.RawMessage `json:"raw"`
+		Any      interface{}        `json:"any"`
+		Array    [2]int32           `json:"array"`
+	}
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("coverage", &v)
+	if len(o.Components.Schemas) != 1 {
+		t.Fatalf("Expect time.Time not added to components, got %v", sortedSchemaKeys(o.Components.Schemas))
+	}
+	raw, err := json.Marshal(o.Components.Schemas["coverage"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"object","properties":{"int8":{"type":"integer","format":"int32","maximum":127,"minimum":-128},` +
+		`"uint8":{"type":"integer","format":"int32","maximum":255,"minimum":0},` +
+		`"uint16":{"type":"integer","format":"int32","maximum":65535,"minimum":0},` +
+		`"uint64":{"type":"integer","format":"int64","minimum":0},` +
+		`"float32":{"type":"number","format":"float"},` +
+		`"float64":{"type":"number","format":"double"},` +
+		`"bytes":{"type":"string","format":"byte","nullable":true},` +
+		`"time":{"type":"string","format":"date-time"},` +
+		`"timePtr":{"type":"string","format":"date-time","nullable":true},` +
+		`"duration":{"type":"integer","format":"int64"},` +
+		`"number":{"type":"number"},` +
+		`"raw":{"nullable":true},` +
+		`"any":{"nullable":true},` +
+		`"array":{"type":"array","items":{"type":"integer","format":"int32"}}},` +
+		`"required":["int8","uint8","uint16","uint64","float32","float64","bytes","time","timePtr","duration","number","raw","any","array"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+}
+
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType(reflect.TypeOf(money{}), func() *Schema {
+		return &Schema{
+			Type:    "string",
+			Pattern: "^[0-9]+\\.[0-9]{2} [A-Z]{3}$",
+		}
+	})
+	defer RegisterType(reflect.TypeOf(money{}), nil)
+	RegisterSQLNullTypes()
+	defer func() {
+		for tv := range sqlNullTypes {
+			RegisterType(tv, nil)
+		}
+	}()
+
+	var v struct {
+		Price    money          `json:"price"`
+		Discount *money         `json:"discount" description:"Discount of price"`
+		Name     sql.NullString `json:"name"`
+		Stock    sql.NullInt64  `json:"stock"`
+	}
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("product", &v)
+	if len(o.Components.Schemas) != 1 {
+		t.Fatalf("Expect registered types not added to components, got %v", sortedSchemaKeys(o.Components.Schemas))
+	}
+	raw, err := json.Marshal(o.Components.Schemas["product"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"object","properties":{"price":{"type":"string","pattern":"^[0-9]+\\.[0-9]{2} [A-Z]{3}$"},` +
+		`"discount":{"type":"string","description":"Discount of price","pattern":"^[0-9]+\\.[0-9]{2} [A-Z]{3}$","nullable":true},` +
+		`"name":{"type":"string","nullable":true},` +
+		`"stock":{"type":"integer","format":"int64","nullable":true}},"required":["price","discount","name","stock"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+
+	s, err := Interface(&money{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != "string" {
+		t.Fatalf("Expect registered schema for pointer, got %v", s)
+	}
+
+	RegisterType(reflect.TypeOf(money{}), nil)
+	s, err = Interface(money{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type != "object" {
+		t.Fatalf("Expect reflected schema after removing registration, got %v", s)
+	}
+}
+
+type bookStatus string
+
+func (bookStatus) SchemaDoc() *Schema {
+	return &Schema{
+		Type: "string",
+		Enum: []interface{}{"draft", "published"},
+	}
+}
+
+func TestSchemaDocScalar(t *testing.T) {
+	s, err := Interface(bookStatus(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Enum) != 2 {
+		t.Fatalf("Expect SchemaDoc of named string, got %v", s)
+	}
+	var v struct {
+		Status bookStatus `json:"status"`
+	}
+	s, err = Interface(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Properties["status"].Enum) != 2 {
+		t.Fatalf("Expect SchemaDoc of named string field, got %v", s.Properties["status"])
+	}
+}
+
+type audit struct {
+	Created string `json:"created"`
+	Updated string `json:"updated,omitempty"`
+}
+
+type owner struct {
+	Owner string `json:"Owner"`
+}
+
+type team struct {
+	Owner string
+}
+
+type history struct {
+	Note string
+}
+
+type remark struct {
+	Note string
+}
+
+func TestJSONSemantics(t *testing.T) {
+	var v struct {
+		audit
+		*owner
+		team
+		Book     `json:"book"`
+		ID       int64  `json:"id,string"`
+		Title    string // untagged fields get Go name
+		Updated  string `json:"updated"`
+		Ignored  string `json:"-"`
+		Dash     string `json:"-,"`
+		internal string
+	}
+	schema, err := Interface(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tagged Owner dominates the untagged one at the same depth, and updated is shadowed by the shallower one
+	expect := `{"type":"object","properties":{"created":{"type":"string"},"Owner":{"type":"string"},` +
+		`"book":{"type":"object","properties":{"name":{"type":"string","maxLength":128,"minLength":1},` +
+		`"author":{"type":"string","maxLength":128,"minLength":1},"date":{"type":"string","format":"date"}},` +
+		`"required":["name","author","date"]},` +
+		`"id":{"type":"string"},"Title":{"type":"string"},"updated":{"type":"string"},"-":{"type":"string"}},` +
+		`"required":["created","Owner","book","id","Title","updated","-"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+
+	// Conflicting fields at the same depth are dropped
+	var conflict struct {
+		audit
+		history
+		remark
+	}
+	schema, err = Interface(conflict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.Properties["Note"]; ok {
+		t.Fatal("Expect conflicting Note dropped")
+	}
+	if _, ok := schema.Properties["updated"]; !ok || strings.Join(schema.Required.Properties, ",") != "created" {
+		t.Fatalf("Expect optional updated, got %v", schema.Required.Properties)
+	}
+}
+
+type order struct {
+	Status   string            `json:"status" validate:"oneof=pending paid 'on hold'"`
+	Code     string            `json:"code" validate:"len=6"`
+	Quantity int               `json:"quantity" validate:"gt=0,lte=100"`
+	Discount float64           `json:"discount" validate:"gte=0,lt=1"`
+	Email    string            `json:"email" validate:"required,email"`
+	Site     string            `json:"site,omitempty" validate:"omitempty,url"`
+	ID       string            `json:"id" validate:"uuid4"`
+	IP       string            `json:"ip" validate:"ipv4"`
+	Day      string            `json:"day" validate:"datetime=2006-01-02"`
+	Tags     []string          `json:"tags" validate:"min=1,max=5,unique,dive,min=2,oneof=a1 b2 c3"`
+	Labels   map[string]string `json:"labels" validate:"gt=0,dive,max=16"`
+	Books    []Book            `json:"books" validate:"dive,required"`
+}
+
+func TestValidateTags(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("order", &order{})
+	schema := o.Components.Schemas["order"]
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"object","properties":{"status":{"type":"string","enum":["pending","paid","on hold"]},` +
+		`"code":{"type":"string","maxLength":6,"minLength":6},` +
+		`"quantity":{"type":"integer","format":"int64","maximum":100,"minimum":0,"exclusiveMinimum":true},` +
+		`"discount":{"type":"number","format":"double","maximum":1,"exclusiveMaximum":true,"minimum":0},` +
+		`"email":{"type":"string","format":"email"},"site":{"type":"string","format":"uri"},` +
+		`"id":{"type":"string","format":"uuid"},"ip":{"type":"string","format":"ipv4"},"day":{"type":"string","format":"date"},` +
+		`"tags":{"type":"array","items":{"type":"string","minLength":2,"enum":["a1","b2","c3"]},"maxItems":5,"minItems":1,"uniqueItems":true,"nullable":true},` +
+		`"labels":{"type":"object","additionalProperties":{"type":"string","maxLength":16},"minProperties":1,"nullable":true},` +
+		`"books":{"type":"array","items":{"$ref":"#/components/schemas/go-openapi.Book"},"nullable":true}},` +
+		`"required":["status","code","quantity","discount","email","id","ip","day","tags","labels","books"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	checker := newValueChecker(o)
+	var v interface{}
+	body := `{"status":"lost","code":"abc","quantity":0,"discount":1,"email":"nobody","site":"/relative",` +
+		`"id":"123","ip":"::1","day":"2020-13-01","tags":["a1","a1"],"labels":{},"books":[]}`
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, violation := range checker.check(schema, v, "") {
+		got = append(got, violation.pointer)
+	}
+	expectPointers := "/code,/day,/discount,/email,/id,/ip,/labels,/quantity,/site,/status,/tags"
+	if strings.Join(got, ",") != expectPointers {
+		t.Fatalf("Expect violations at %s, got %v", expectPointers, got)
+	}
+}
+
+func TestValidateTagErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		v    interface{}
+	}{
+		{"bad-bound", struct {
+			A int `json:"a" validate:"gt=a"`
+		}{}},
+		{"dive-on-string", struct {
+			A string `json:"a" validate:"dive,min=1"`
+		}{}},
+		{"bound-on-bool", struct {
+			A bool `json:"a" validate:"len=1"`
+		}{}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := Interface(tC.v); err == nil {
+				t.Fatal("Expect error")
+			}
+		})
+	}
+}
+
+type bookStand struct {
+	Book  Book `json:"book" validate:"min=1"`
+	Novel Book `json:"novel" validate:"required"`
+}
+
+func TestValidateRefField(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("stand", &bookStand{})
+	schema := o.Components.Schemas["stand"]
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bounds of ref are put on a wrapper, which is not needed if the field is only required
+	expect := `{"type":"object","properties":{"book":{"allOf":[{"$ref":"#/components/schemas/go-openapi.Book"}],"minProperties":1},` +
+		`"novel":{"$ref":"#/components/schemas/go-openapi.Book"}},"required":["book","novel"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	checker := newValueChecker(o)
+	var v interface{}
+	if err := json.Unmarshal([]byte(`{"book":{},"novel":{"name":"Go","author":"Rob","date":"2020-01-02"}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, violation := range checker.check(schema, v, "") {
+		got = append(got, violation.pointer+": "+violation.message)
+	}
+	if len(got) == 0 || got[0] != "/book: 0 properties are less than minProperties 1" {
+		t.Fatalf("Expect minProperties violation of /book, got %v", got)
+	}
+}
+
+type invoice struct {
+	ID       int64    `json:"id" readOnly:"true" title:"Invoice ID"`
+	Priority int      `json:"priority" enum:"1|2|3" default:"2" example:"3"`
+	Amount   float64  `json:"amount" multipleOf:"0.01"`
+	Secret   string   `json:"secret,omitempty" writeOnly:"true"`
+	Note     *string  `json:"note" nullable:"true" deprecated:"true"`
+	Lines    []string `json:"lines" example:"[\"a\",\"b\"]"`
+}
+
+func TestSchemaKeywords(t *testing.T) {
+	schema, err := Interface(invoice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"type":"object","properties":{"id":{"title":"Invoice ID","type":"integer","format":"int64","readOnly":true},` +
+		`"priority":{"type":"integer","format":"int64","default":2,"enum":[1,2,3],"example":3},` +
+		`"amount":{"type":"number","format":"double","multipleOf":0.01},` +
+		`"secret":{"type":"string","writeOnly":true},` +
+		`"note":{"type":"string","nullable":true,"deprecated":true},` +
+		`"lines":{"type":"array","items":{"type":"string"},"nullable":true,"example":["a","b"]}},` +
+		`"required":["id","priority","amount","note","lines"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+
+	// Keywords survive a round trip
+	var parsed Schema
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	again, err := json.Marshal(&parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(again))
+	}
+
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := newValueChecker(o)
+	testCases := []struct {
+		value interface{}
+		valid bool
+	}{
+		{map[string]interface{}{"id": 1.0, "priority": 2.0, "amount": 1.25, "note": nil, "lines": []interface{}{}}, true},
+		{map[string]interface{}{"id": 1.0, "priority": 4.0, "amount": 1.25, "note": nil, "lines": []interface{}{}}, false},
+		{map[string]interface{}{"id": 1.0, "priority": 1.0, "amount": 1.255, "note": nil, "lines": []interface{}{}}, false},
+	}
+	for i, tC := range testCases {
+		if violations := checker.check(schema, tC.value, ""); (len(violations) == 0) != tC.valid {
+			t.Fatalf("Case %d: expect valid %v, got %v", i, tC.valid, violations)
+		}
+	}
+}
+
+func TestSchemaBuilders(t *testing.T) {
+	s := NewSchema("object").
+		WithTitle("Payment").
+		WithDiscriminator("kind", map[string]string{"card": "#/components/schemas/card"}).
+		WithXML(&XML{Name: "payment"}).
+		WithExternalDocs("https://example.com/payment", "Payment guide").
+		WithPropertiesRange(1, -1).
+		WithProperty("amount", true, NewSchema("number").WithMinimum(0, true).WithMaximum(100, false).WithMultipleOf(0.5)).
+		WithProperty("kind", true, NewSchema("string").WithEnum("card", "cash").WithDefault("cash").WithLength(1, 8)).
+		WithProperty("tags", false, NewSchema("array").WithItems(&Schema{Type: "string"}).WithItemsRange(-1, 3).WithUniqueItems(true).WithNullable(true))
+	raw, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"title":"Payment","type":"object","properties":{` +
+		`"amount":{"type":"number","multipleOf":0.5,"maximum":100,"minimum":0,"exclusiveMinimum":true},` +
+		`"kind":{"type":"string","default":"cash","maxLength":8,"minLength":1,"enum":["card","cash"]},` +
+		`"tags":{"type":"array","items":{"type":"string"},"maxItems":3,"uniqueItems":true,"nullable":true}},` +
+		`"minProperties":1,"required":["amount","kind"],` +
+		`"discriminator":{"propertyName":"kind","mapping":{"card":"#/components/schemas/card"}},` +
+		`"xml":{"name":"payment"},"externalDocs":{"description":"Payment guide","url":"https://example.com/payment"}}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+}
+
+type payment interface {
+	paymentKind() string
+}
+
+type card struct {
+	Kind   string `json:"kind"`
+	Number string `json:"number"`
+}
+
+func (card) paymentKind() string { return "card" }
+
+type cash struct {
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+}
+
+func (*cash) paymentKind() string { return "cash" }
+
+type checkout struct {
+	Payment  payment   `json:"payment"`
+	Payments []payment `json:"payments,omitempty"`
+}
+
+func TestImplementations(t *testing.T) {
+	paymentType := reflect.TypeOf((*payment)(nil)).Elem()
+	RegisterImplementations(paymentType, "kind", map[string]interface{}{
+		"card": card{},
+		"cash": &cash{},
+	})
+	defer RegisterImplementations(paymentType, "", nil)
+
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.MustGetSchema("checkout", &checkout{})
+	raw, err := json.Marshal(o.Components.Schemas["checkout"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneOf := `"oneOf":[{"$ref":"#/components/schemas/go-openapi.card"},{"$ref":"#/components/schemas/go-openapi.cash"}]`
+	discriminator := `"discriminator":{"propertyName":"kind","mapping":{"card":"#/components/schemas/go-openapi.card","cash":"#/components/schemas/go-openapi.cash"}}`
+	// Interface values without omitempty are encoded as null when nil
+	expect := `{"type":"object","properties":{"payment":{` + oneOf + `,"nullable":true,` + discriminator + `},` +
+		`"payments":{"type":"array","items":{` + oneOf + `,` + discriminator + `}}},"required":["payment"]}`
+	if string(raw) != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, string(raw))
+	}
+	if errs := o.Validate(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	// Implementations are inlined without root
+	schema, err := Interface(checkout{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inline := schema.Properties["payment"]
+	if len(inline.OneOf) != 2 || inline.OneOf[1].Properties["currency"] == nil || inline.Discriminator.Mapping != nil {
+		t.Fatalf("Expect inlined implementations, got %v", inline)
+	}
+}
+
+func TestRegisterImplementationsPanics(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		iface reflect.Type
+		impls map[string]interface{}
+	}{
+		{"not-interface", reflect.TypeOf(card{}), map[string]interface{}{"card": card{}}},
+		{"not-implemented", reflect.TypeOf((*payment)(nil)).Elem(), map[string]interface{}{"cash": cash{}}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Expect panic")
+				}
+			}()
+			RegisterImplementations(tC.iface, "kind", tC.impls)
+		})
+	}
+}
+
+func TestAnyOfRef(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Schema{}
+	o.AddSchema("bookOrPerson", s)
+	s.WithAnyOf(&Book{}, person{}, "")
+	if len(s.AnyOf) != 3 || s.AnyOf[0].Ref != "#/components/schemas/go-openapi.Book" ||
+		s.AnyOf[1].Ref != "#/components/schemas/go-openapi.person" || s.AnyOf[2].Type != "string" {
+		t.Fatalf("Expect refs to components, got %v", s.AnyOf)
+	}
+}
@@ -0,0 +1,466 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// style return declared style of param, or the default style of its location
+func (p *Param) style() ParamStyle {
+	if p.Style != "" {
+		return p.Style
+	}
+	if p.In == QueryParam || p.In == CookieParam {
+		return FormStyle
+	}
+	return SimpleStyle
+}
+
+// explode return declared explode of param, it's true by default only for form style
+func (p *Param) explode() bool {
+	if p.Explode != nil {
+		return *p.Explode
+	}
+	return p.style() == FormStyle
+}
+
+// undeclared tells whether param has no declared serialization,
+// such params accept both repeated and comma separated values of arrays, and JSON of objects
+func (p *Param) undeclared() bool {
+	return p.Style == "" && p.Explode == nil
+}
+
+// ParseValue read value of param from request r, converted to the type declared by its schema or content.
+// Values are split according to style and explode of param, path values are read with PathValues.
+// ok is false if param is absent
+func (p *Param) ParseValue(r *http.Request) (value interface{}, ok bool, err error) {
+	c := newValueChecker(p.root)
+	param, err := c.resolveParam(p)
+	if err != nil {
+		return nil, false, err
+	}
+	return c.paramValue(r, r.URL.Query(), PathValues(r), param)
+}
+
+// paramSchema return resolved schema of param, or schema of its content
+func (c *valueChecker) paramSchema(param *Param) (*Schema, error) {
+	for _, media := range param.Content {
+		return c.resolve(media.Schema)
+	}
+	return c.resolve(param.Schema)
+}
+
+// paramValue read value of param from request, converted to the type declared by its schema or content.
+// query is the parsed query of request, ok is false if param is absent
+func (c *valueChecker) paramValue(r *http.Request, query url.Values, pathValues map[string]string, param *Param) (value interface{}, ok bool, err error) {
+	schema, err := c.paramSchema(param)
+	if err != nil {
+		return nil, false, err
+	}
+	// Properties of exploded objects are separate query params
+	if schema != nil && schema.Type == "object" && param.In == QueryParam && len(param.Content) == 0 {
+		style := param.style()
+		if style == DeepObjectStyle || style == FormStyle && param.explode() && !param.undeclared() {
+			pairs := queryPairs(query, param, schema)
+			if len(pairs) == 0 {
+				return nil, false, nil
+			}
+			value, err := c.objectValue(schema, pairs)
+			return value, true, err
+		}
+	}
+
+	values := requestValues(r, query, pathValues, param)
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+	if values[0] == "" && param.AllowEmptyValue {
+		return "", true, nil
+	}
+	for mimeType := range param.Content {
+		if !isJSONMime(mimeType) {
+			return values[0], true, nil
+		}
+		if err := json.Unmarshal([]byte(values[0]), &value); err != nil {
+			return nil, true, fmt.Errorf("invalid JSON:%s", err.Error())
+		}
+		return value, true, nil
+	}
+	if param.In == HeaderParam {
+		values = []string{strings.Join(values, ",")}
+	}
+
+	var schemaType string
+	if schema != nil {
+		schemaType = schema.Type
+	}
+	switch schemaType {
+	case "array":
+		items, err := styleItems(param, values, false)
+		if err != nil {
+			return nil, true, err
+		}
+		itemSchema, err := c.resolve(schema.Items)
+		if err != nil {
+			return nil, true, err
+		}
+		array := make([]interface{}, len(items))
+		for i, item := range items {
+			if array[i], err = scalarValue(itemSchema, item); err != nil {
+				return nil, true, err
+			}
+		}
+		return array, true, nil
+	case "object":
+		if param.undeclared() {
+			var obj interface{}
+			if err := json.Unmarshal([]byte(values[0]), &obj); err != nil {
+				return nil, true, err
+			}
+			return obj, true, nil
+		}
+		pairs, err := styleItems(param, values, true)
+		if err != nil {
+			return nil, true, err
+		}
+		value, err := c.objectValue(schema, pairs)
+		return value, true, err
+	default:
+		raw, err := styleScalar(param, values[0])
+		if err != nil {
+			return nil, true, err
+		}
+		value, err := scalarValue(schema, raw)
+		return value, true, err
+	}
+}
+
+// objectValue convert alternate keys and values to an object, values are converted to types of properties
+func (c *valueChecker) objectValue(schema *Schema, pairs []string) (map[string]interface{}, error) {
+	obj := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := pairs[i]
+		propSchema, ok := schema.Properties[key]
+		if !ok {
+			propSchema = schema.AdditionalProperties
+		}
+		propSchema, err := c.resolve(propSchema)
+		if err != nil {
+			return nil, err
+		}
+		value, err := scalarValue(propSchema, pairs[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("property %s:%s", key, err.Error())
+		}
+		obj[key] = value
+	}
+	return obj, nil
+}
+
+// queryPairs collect properties of exploded object param from query, like color[R]=100 of deepObject style,
+// or R=100 of form style, as alternate keys and values
+func queryPairs(query url.Values, param *Param, schema *Schema) []string {
+	var pairs []string
+	if param.style() == DeepObjectStyle {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		prefix := param.Name + "["
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, "]") {
+				pairs = append(pairs, key[len(prefix):len(key)-1], query.Get(key))
+			}
+		}
+		return pairs
+	}
+	for _, key := range sortedSchemaKeys(schema.Properties) {
+		if values, ok := query[key]; ok {
+			pairs = append(pairs, key, values[0])
+		}
+	}
+	return pairs
+}
+
+// styleScalar strip prefix of primitive value of param, like . of label style
+func styleScalar(param *Param, value string) (string, error) {
+	switch param.style() {
+	case LabelStyle:
+		if !strings.HasPrefix(value, ".") {
+			return "", fmt.Errorf("expect label value starting with ., got %q", value)
+		}
+		return value[1:], nil
+	case MatrixStyle:
+		prefix := ";" + param.Name
+		if value == prefix {
+			return "", nil
+		}
+		if !strings.HasPrefix(value, prefix+"=") {
+			return "", fmt.Errorf("expect matrix value starting with %s=, got %q", prefix, value)
+		}
+		return value[len(prefix)+1:], nil
+	default:
+		return value, nil
+	}
+}
+
+// styleItems split raw values of param into items of an array, or alternate keys and values of an object,
+// according to style and explode of param
+func styleItems(param *Param, values []string, object bool) ([]string, error) {
+	style, explode := param.style(), param.explode()
+	value := values[0]
+	var items []string
+	switch style {
+	case LabelStyle:
+		if !strings.HasPrefix(value, ".") {
+			return nil, fmt.Errorf("expect label value starting with ., got %q", value)
+		}
+		if explode {
+			items = strings.Split(value[1:], ".")
+		} else {
+			items = strings.Split(value[1:], ",")
+		}
+	case MatrixStyle:
+		if !explode {
+			raw, err := styleScalar(param, value)
+			if err != nil {
+				return nil, err
+			}
+			items = strings.Split(raw, ",")
+			break
+		}
+		if !strings.HasPrefix(value, ";") {
+			return nil, fmt.Errorf("expect matrix value starting with ;, got %q", value)
+		}
+		items = strings.Split(value[1:], ";")
+		if !object {
+			for i, item := range items {
+				raw, err := styleScalar(param, ";"+item)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = raw
+			}
+		}
+	case SimpleStyle:
+		items = strings.Split(value, ",")
+	case DeepObjectStyle:
+		return nil, fmt.Errorf("deepObject style is only for objects")
+	default:
+		sep := ","
+		if style == SpaceDelimitedStyle {
+			sep = " "
+		} else if style == PipeDelimitedStyle {
+			sep = "|"
+		}
+		if explode && !object {
+			items = values
+			if len(values) == 1 && param.undeclared() {
+				items = strings.Split(value, sep)
+			}
+		} else {
+			items = strings.Split(value, sep)
+		}
+	}
+	if !object {
+		return items, nil
+	}
+	if explode && (style == SimpleStyle || style == LabelStyle || style == MatrixStyle) {
+		pairs := make([]string, 0, 2*len(items))
+		for _, item := range items {
+			i := strings.Index(item, "=")
+			if i < 0 {
+				return nil, fmt.Errorf("expect key=value, got %q", item)
+			}
+			pairs = append(pairs, item[:i], item[i+1:])
+		}
+		return pairs, nil
+	}
+	if len(items)%2 != 0 {
+		return nil, fmt.Errorf("expect pairs of keys and values, got %d items", len(items))
+	}
+	return items, nil
+}
+
+// FormatValue serialize v as param according to its style and explode, like examples of the OpenAPI specification.
+// For example, arrays are color=blue&color=black in query, and objects are .R=100.G=200 in path with exploded label style.
+// Values are encoded as JSON for params with JSON content. Query values are percent-encoded unless reserved
+// characters are allowed, and path values are percent-encoded
+func (p *Param) FormatValue(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	for mimeType := range p.Content {
+		if isJSONMime(mimeType) {
+			return p.formatScalar(string(raw)), nil
+		}
+		return p.formatScalar(fmt.Sprint(v)), nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = p.escape(formatPrimitive(item))
+		}
+		return p.formatArray(items)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, 2*len(keys))
+		for _, key := range keys {
+			pairs = append(pairs, p.escape(key), p.escape(formatPrimitive(value[key])))
+		}
+		return p.formatObject(pairs), nil
+	default:
+		return p.formatScalar(formatPrimitive(value)), nil
+	}
+}
+
+func (p *Param) formatScalar(value string) string {
+	value = p.escape(value)
+	switch p.style() {
+	case MatrixStyle:
+		return ";" + p.Name + "=" + value
+	case LabelStyle:
+		return "." + value
+	case SimpleStyle:
+		return value
+	default:
+		return p.Name + "=" + value
+	}
+}
+
+func (p *Param) formatArray(items []string) (string, error) {
+	explode := p.explode()
+	switch p.style() {
+	case MatrixStyle:
+		if explode {
+			return ";" + p.Name + "=" + strings.Join(items, ";"+p.Name+"="), nil
+		}
+		return ";" + p.Name + "=" + strings.Join(items, ","), nil
+	case LabelStyle:
+		if explode {
+			return "." + strings.Join(items, "."), nil
+		}
+		return "." + strings.Join(items, ","), nil
+	case SimpleStyle:
+		return strings.Join(items, ","), nil
+	case DeepObjectStyle:
+		return "", fmt.Errorf("deepObject style is only for objects")
+	}
+	if explode {
+		return p.Name + "=" + strings.Join(items, "&"+p.Name+"="), nil
+	}
+	sep := ","
+	if p.style() == SpaceDelimitedStyle {
+		sep = "%20"
+	} else if p.style() == PipeDelimitedStyle {
+		sep = "|"
+	}
+	return p.Name + "=" + strings.Join(items, sep), nil
+}
+
+// formatObject format alternate keys and values of object
+func (p *Param) formatObject(pairs []string) string {
+	explode := p.explode()
+	entries := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if p.style() == DeepObjectStyle {
+			entries = append(entries, p.Name+"["+pairs[i]+"]="+pairs[i+1])
+		} else {
+			entries = append(entries, pairs[i]+"="+pairs[i+1])
+		}
+	}
+	switch p.style() {
+	case MatrixStyle:
+		if explode {
+			return ";" + strings.Join(entries, ";")
+		}
+		return ";" + p.Name + "=" + strings.Join(pairs, ",")
+	case LabelStyle:
+		if explode {
+			return "." + strings.Join(entries, ".")
+		}
+		return "." + strings.Join(pairs, ",")
+	case SimpleStyle:
+		if explode {
+			return strings.Join(entries, ",")
+		}
+		return strings.Join(pairs, ",")
+	case DeepObjectStyle:
+		return strings.Join(entries, "&")
+	}
+	if explode {
+		return strings.Join(entries, "&")
+	}
+	sep := ","
+	if p.style() == SpaceDelimitedStyle {
+		sep = "%20"
+	} else if p.style() == PipeDelimitedStyle {
+		sep = "|"
+	}
+	return p.Name + "=" + strings.Join(pairs, sep)
+}
+
+// escape percent-encode value for location of param
+func (p *Param) escape(value string) string {
+	switch p.In {
+	case QueryParam:
+		if p.AllowReserved {
+			return escapeUnreserved(value, ":/?#[]@!$&'()*+,;=")
+		}
+		return url.QueryEscape(value)
+	case PathParam:
+		return url.PathEscape(value)
+	default:
+		return value
+	}
+}
+
+// escapeUnreserved percent-encode bytes of value other than unreserved characters and kept
+func escapeUnreserved(value, kept string) string {
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("-._~", c) >= 0 || strings.IndexByte(kept, c) >= 0 {
+			buf.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&buf, "%%%02X", c)
+	}
+	return buf.String()
+}
+
+// formatPrimitive format decoded JSON value, nested arrays and objects are kept in JSON
+func formatPrimitive(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case fmt.Stringer:
+		return value.String()
+	default:
+		raw, _ := json.Marshal(value)
+		return string(raw)
+	}
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type color struct {
+	R int `json:"R"`
+	G int `json:"G"`
+}
+
+func TestParamStyles(t *testing.T) {
+	colors := []string{"blue", "black"}
+	rgb := color{R: 100, G: 200}
+	arraySchema := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	objectSchema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"R": {Type: "integer"},
+			"G": {Type: "integer"},
+		},
+	}
+	testCases := []struct {
+		in      ParamType
+		style   ParamStyle
+		explode bool
+		value   interface{}
+		expect  string
+	}{
+		{PathParam, SimpleStyle, false, 5, "5"},
+		{PathParam, LabelStyle, false, 5, ".5"},
+		{PathParam, MatrixStyle, false, 5, ";color=5"},
+		{PathParam, SimpleStyle, false, colors, "blue,black"},
+		{PathParam, LabelStyle, false, colors, ".blue,black"},
+		{PathParam, LabelStyle, true, colors, ".blue.black"},
+		{PathParam, MatrixStyle, false, colors, ";color=blue,black"},
+		{PathParam, MatrixStyle, true, colors, ";color=blue;color=black"},
+		{PathParam, SimpleStyle, true, rgb, "G=200,R=100"},
+		{PathParam, LabelStyle, true, rgb, ".G=200.R=100"},
+		{PathParam, MatrixStyle, false, rgb, ";color=G,200,R,100"},
+		{PathParam, MatrixStyle, true, rgb, ";G=200;R=100"},
+		{HeaderParam, SimpleStyle, false, colors, "blue,black"},
+		{QueryParam, FormStyle, true, colors, "color=blue&color=black"},
+		{QueryParam, FormStyle, false, colors, "color=blue,black"},
+		{QueryParam, FormStyle, true, rgb, "G=200&R=100"},
+		{QueryParam, FormStyle, false, rgb, "color=G,200,R,100"},
+		{QueryParam, SpaceDelimitedStyle, false, colors, "color=blue%20black"},
+		{QueryParam, PipeDelimitedStyle, false, colors, "color=blue|black"},
+		{QueryParam, DeepObjectStyle, true, rgb, "color[G]=200&color[R]=100"},
+	}
+	for _, tC := range testCases {
+		desc := string(tC.in) + "-" + string(tC.style)
+		param := (&Param{Name: "color", In: tC.in, Required: tC.in == PathParam}).WithStyle(tC.style, tC.explode)
+		var expectValue interface{}
+		switch v := tC.value.(type) {
+		case int:
+			param.Schema = &Schema{Type: "integer"}
+			expectValue = int64(v)
+		case []string:
+			param.Schema = arraySchema
+			expectValue = []interface{}{"blue", "black"}
+		case color:
+			param.Schema = objectSchema
+			expectValue = map[string]interface{}{"R": int64(100), "G": int64(200)}
+		}
+		formatted, err := param.FormatValue(tC.value)
+		if err != nil {
+			t.Fatalf("%s: %v", desc, err)
+		}
+		if formatted != tC.expect {
+			t.Fatalf("%s: expect %s, got %s", desc, tC.expect, formatted)
+		}
+
+		target := "/"
+		req := httptest.NewRequest("GET", target, nil)
+		switch tC.in {
+		case PathParam:
+			req = req.WithContext(context.WithValue(req.Context(), pathValuesKey{}, map[string]string{"color": formatted}))
+		case QueryParam:
+			req = httptest.NewRequest("GET", "/?"+formatted, nil)
+		case HeaderParam:
+			req.Header.Set("color", formatted)
+		}
+		value, ok, err := param.ParseValue(req)
+		if err != nil || !ok {
+			t.Fatalf("%s: expect value, got %v %v", desc, ok, err)
+		}
+		if !reflect.DeepEqual(expectValue, value) {
+			t.Fatalf("%s: expect %#v, got %#v", desc, expectValue, value)
+		}
+	}
+}
+
+func TestParamContent(t *testing.T) {
+	param := (&Param{Name: "filter", In: QueryParam}).WithContent(MimeJSON, &Book{})
+	formatted, err := param.FormatValue(&Book{Name: "Go, more", Author: "Rob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `filter=%7B%22name%22%3A%22Go%2C+more%22%2C%22author%22%3A%22Rob%22%2C%22date%22%3A%22%22%7D`
+	if formatted != expect {
+		t.Fatalf("expect %s, got %s", expect, formatted)
+	}
+	value, ok, err := param.ParseValue(httptest.NewRequest("GET", "/?"+formatted, nil))
+	if err != nil || !ok {
+		t.Fatalf("expect value, got %v %v", ok, err)
+	}
+	expectValue := map[string]interface{}{"name": "Go, more", "author": "Rob", "date": ""}
+	if !reflect.DeepEqual(expectValue, value) {
+		t.Fatalf("expect %v, got %v", expectValue, value)
+	}
+
+	param = (&Param{Name: "path", In: QueryParam}).SetAllowReserved()
+	if formatted, _ := param.FormatValue("/a b?"); formatted != "path=/a%20b?" {
+		t.Fatalf("expect reserved characters kept, got %s", formatted)
+	}
+}
+
+func TestParamStyleErrors(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.CollectErrors()
+	(&Param{root: o, Name: "ids", In: HeaderParam}).WithStyle(FormStyle, true)
+	(&Param{root: o, Name: "ids", In: PathParam}).SetAllowReserved()
+	if errs, ok := o.Err().(BuildErrors); !ok || len(errs) != 2 {
+		t.Fatalf("expect 2 build errors, got %v", o.Err())
+	}
+
+	o, _ = New("3.0.0", sampleInfo)
+	NewRouter(o).GET("/books", "List books", "").
+		WithParam(&Param{
+			Name:   "ids",
+			In:     HeaderParam,
+			Style:  DeepObjectStyle,
+			Schema: &Schema{Type: "string"},
+			Content: mediaTypeMap{
+				MimeJSON: {Schema: &Schema{Type: "string"}},
+			},
+		}).
+		Returns(200, "Books", "", nil)
+	var got []string
+	for _, err := range o.Validate() {
+		got = append(got, err.Error())
+	}
+	expect := []string{
+		"/paths/~1books/get/parameters/0/style: style deepObject is not allowed for header param",
+		"/paths/~1books/get/parameters/0: schema and content are mutually exclusive",
+	}
+	if !reflect.DeepEqual(expect, got) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+}
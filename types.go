@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// typeRegistry keeps functions creating schemas of registered types, and implementations of interface types
+var typeRegistry = struct {
+	sync.RWMutex
+	schemas         map[reflect.Type]func() *Schema
+	implementations map[reflect.Type]*implementations
+}{
+	schemas:         make(map[reflect.Type]func() *Schema),
+	implementations: make(map[reflect.Type]*implementations),
+}
+
+// implementations of an interface type, told apart by value of a property
+type implementations struct {
+	propertyName string
+	types        map[string]reflect.Type
+}
+
+// RegisterType use fn to create schema for type tv and pointers to it, instead of reflecting on it.
+// It's for types that can't implement SchemaDoc, like uuid.UUID of other packages.
+// fn should return a new schema on each call, since it may be modified later. A nil fn removes the registration
+func RegisterType(tv reflect.Type, fn func() *Schema) {
+	typeRegistry.Lock()
+	defer typeRegistry.Unlock()
+	if fn == nil {
+		delete(typeRegistry.schemas, tv)
+		return
+	}
+	typeRegistry.schemas[tv] = fn
+}
+
+// registeredSchema return schema of registered type tv, or of the type tv points to
+func registeredSchema(tv reflect.Type) *Schema {
+	typeRegistry.RLock()
+	var fn func() *Schema
+	for tv != nil && fn == nil {
+		fn = typeRegistry.schemas[tv]
+		if tv.Kind() != reflect.Ptr {
+			break
+		}
+		tv = tv.Elem()
+	}
+	typeRegistry.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+// RegisterImplementations register implementations of interface type iface, so values of iface are described
+// with oneOf schemas of the implementations and a discriminator on propertyName.
+// impls maps values of the property to samples of implementations. A nil impls removes the registration
+func RegisterImplementations(iface reflect.Type, propertyName string, impls map[string]interface{}) {
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Errorf("%s is not an interface type", iface.String()))
+	}
+	typeRegistry.Lock()
+	defer typeRegistry.Unlock()
+	if impls == nil {
+		delete(typeRegistry.implementations, iface)
+		return
+	}
+	registered := &implementations{
+		propertyName: propertyName,
+		types:        make(map[string]reflect.Type, len(impls)),
+	}
+	for value, impl := range impls {
+		tv := reflect.TypeOf(impl)
+		if tv == nil || !tv.Implements(iface) {
+			panic(fmt.Errorf("%v of %s doesn't implement %s", tv, value, iface.String()))
+		}
+		registered.types[value] = tv
+	}
+	typeRegistry.implementations[iface] = registered
+}
+
+func registeredImplementations(tv reflect.Type) *implementations {
+	typeRegistry.RLock()
+	defer typeRegistry.RUnlock()
+	return typeRegistry.implementations[tv]
+}
+
+// sqlNullTypes are sql.Null* wrappers and types of values they wrap
+var sqlNullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+}
+
+// RegisterSQLNullTypes map sql.Null* wrappers to nullable primitives.
+// encoding/json encodes them as objects like {"String":"","Valid":false},
+// so register them only if they are encoded as the value or null, by custom marshalers for example
+func RegisterSQLNullTypes() {
+	for tv, valueType := range sqlNullTypes {
+		valueType := valueType
+		RegisterType(tv, func() *Schema {
+			schema := typeSchema(valueType)
+			schema.Nullable = true
+			return schema
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.13
+// +build go1.13
+
+package openapi
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+func init() {
+	sqlNullTypes[reflect.TypeOf(sql.NullInt32{})] = reflect.TypeOf(int32(0))
+	sqlNullTypes[reflect.TypeOf(sql.NullTime{})] = reflect.TypeOf(time.Time{})
+}
@@ -4,6 +4,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"unicode"
 )
 
 // DefaultOperationID provide default operation id generator
@@ -41,3 +42,15 @@ func genInterfaceKey(v interface{}) string {
 	key := prefix + path.Base(fullPath)
 	return key
 }
+
+// typeKey is key of named type in components, like package.TypeName
+func typeKey(tp reflect.Type) string {
+	key := path.Base(tp.PkgPath()) + "." + tp.Name()
+	// Names of generic types have type arguments like Page[pkg.Book]
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,471 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+// ValidationError is a problem found in document.
+// Location is a JSON pointer to where the problem is, e.g. /paths/~1books/get/responses
+type ValidationError struct {
+	Location string
+	Message  string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Location + ": " + e.Message
+}
+
+// validate use json names of fields, so errors can be located in document
+var validate = newValidate()
+
+// schemaFormatTag is the rule of format values declared on Schema
+var schemaFormatTag = func() string {
+	f, _ := reflect.TypeOf(Schema{}).FieldByName("Format")
+	return "omitempty," + f.Tag.Get("validate")
+}()
+
+var pathTemplateVar = regexp.MustCompile(`{([^{}]+)}`)
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// Validate check the whole document and return all problems found.
+// Refs to other files are loaded relative to working directory.
+func (o *OpenAPI) Validate() []error {
+	v := &docValidator{
+		root:        o,
+		resolver:    o.Resolver(""),
+		operationID: make(map[string]string),
+		visiting:    make(map[*Schema]bool),
+	}
+	v.validate()
+	return v.errs
+}
+
+type docValidator struct {
+	root     *OpenAPI
+	resolver *Resolver
+	errs     []error
+	// operationId -> location where it's first seen
+	operationID map[string]string
+	visiting    map[*Schema]bool
+}
+
+func (v *docValidator) addError(location string, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// addStructErrors add errors from validator, field names are relative to location
+func (v *docValidator) addStructErrors(location string, err error) {
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		if err != nil {
+			v.addError(location, "%s", err.Error())
+		}
+		return
+	}
+	for _, fe := range fieldErrors {
+		// Namespace is like Info.license.name, the first part is type name
+		parts := strings.Split(fe.Namespace(), ".")
+		v.addError(pointerJoin(location, parts[1:]...), "failed on rule %s", ruleOf(fe))
+	}
+}
+
+func ruleOf(fe validator.FieldError) string {
+	if fe.Param() == "" {
+		return fe.Tag()
+	}
+	return fe.Tag() + "=" + fe.Param()
+}
+
+func (v *docValidator) validate() {
+	o := v.root
+	if !strings.HasPrefix(o.OpenAPI, "3.") {
+		v.addError("/openapi", "only openapi 3.x is supported, got %q", o.OpenAPI)
+	}
+	v.addStructErrors("/info", validate.Struct(o.Info))
+	for i, server := range o.Servers {
+		location := pointerJoin("/servers", strconv.Itoa(i))
+		v.addStructErrors(location, validate.Struct(server))
+		for name, variable := range server.Variables {
+			v.addStructErrors(pointerJoin(location, "variables", name), validate.Struct(variable))
+		}
+	}
+	v.security("/security", o.Security)
+
+	paths := make([]string, 0, len(o.Paths))
+	for path := range o.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		v.path(path, o.Paths[path])
+	}
+
+	if o.Components == nil {
+		return
+	}
+	c := o.Components
+	for _, key := range sortedSchemaKeys(c.Schemas) {
+		v.schema(pointerJoin("/components/schemas", key), c.Schemas[key])
+	}
+	for _, key := range sortedParamKeys(c.Parameters) {
+		v.param(pointerJoin("/components/parameters", key), c.Parameters[key])
+	}
+	for _, key := range sortedResponseKeys(c.Responses) {
+		v.response(pointerJoin("/components/responses", key), c.Responses[key])
+	}
+	bodies := make([]string, 0, len(c.RequestBodies))
+	for key := range c.RequestBodies {
+		bodies = append(bodies, key)
+	}
+	sort.Strings(bodies)
+	for _, key := range bodies {
+		v.requestBody(pointerJoin("/components/requestBodies", key), c.RequestBodies[key])
+	}
+	schemes := make([]string, 0, len(c.SecuritySchemes))
+	for key := range c.SecuritySchemes {
+		schemes = append(schemes, key)
+	}
+	sort.Strings(schemes)
+	for _, key := range schemes {
+		v.securityScheme(pointerJoin("/components/securitySchemes", key), c.SecuritySchemes[key])
+	}
+}
+
+func (v *docValidator) path(path string, p *Path) {
+	location := pointerJoin("/paths", path)
+	if !strings.HasPrefix(path, "/") {
+		v.addError(location, "path must begin with /")
+	}
+	for i, param := range p.Parameters {
+		v.param(pointerJoin(location, "parameters", strconv.Itoa(i)), param)
+	}
+
+	vars := make(map[string]bool)
+	for _, match := range pathTemplateVar.FindAllStringSubmatch(path, -1) {
+		vars[match[1]] = true
+	}
+	for _, method := range pathMethods {
+		op, ok := p.operations[method]
+		if !ok {
+			continue
+		}
+		opLocation := pointerJoin(location, method)
+		v.operation(opLocation, op)
+
+		// Every template variable must have a path param, and the other way around
+		declared := make(map[string]bool)
+		params := append(append([]*Param{}, p.Parameters...), op.Parameters...)
+		for _, param := range params {
+			param, err := v.resolver.Param(param)
+			if err != nil || param.In != PathParam {
+				continue
+			}
+			declared[param.Name] = true
+			if !vars[param.Name] {
+				v.addError(opLocation, "path param %s is not in path template", param.Name)
+			}
+		}
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !declared[name] {
+				v.addError(opLocation, "no path param for template variable {%s}", name)
+			}
+		}
+	}
+}
+
+func (v *docValidator) operation(location string, op *Operation) {
+	if op.OperationID != "" {
+		if first, exists := v.operationID[op.OperationID]; exists {
+			v.addError(pointerJoin(location, "operationId"), "duplicate operationId %s, first used in %s", op.OperationID, first)
+		} else {
+			v.operationID[op.OperationID] = location
+		}
+	}
+	for i, param := range op.Parameters {
+		v.param(pointerJoin(location, "parameters", strconv.Itoa(i)), param)
+	}
+	if op.Security != nil {
+		v.security(pointerJoin(location, "security"), *op.Security)
+	}
+	if op.RequestBody != nil {
+		v.requestBody(pointerJoin(location, "requestBody"), op.RequestBody)
+	}
+	if len(op.Responses) == 0 {
+		v.addError(pointerJoin(location, "responses"), "no response codes")
+	}
+	for _, code := range sortedResponseKeys(respMap(op.Responses)) {
+		v.response(pointerJoin(location, "responses", code), op.Responses[code])
+	}
+}
+
+func (v *docValidator) param(location string, param *Param) {
+	if param.Ref != "" {
+		v.ref(location, param.Ref, func() error {
+			_, err := v.resolver.Param(param)
+			return err
+		})
+		return
+	}
+	v.addStructErrors(location, validate.StructPartial(param, "Name", "In"))
+	if param.In == PathParam && !param.Required {
+		v.addError(pointerJoin(location, "required"), "path param must be required")
+	}
+	if param.Style != "" && !param.Style.IsValidFor(param.In) {
+		v.addError(pointerJoin(location, "style"), "style %s is not allowed for %s param", param.Style, param.In)
+	}
+	if param.AllowReserved && param.In != QueryParam {
+		v.addError(pointerJoin(location, "allowReserved"), "allowReserved is only for query params")
+	}
+	if param.Schema != nil {
+		v.schema(pointerJoin(location, "schema"), param.Schema)
+	}
+	if param.Content != nil {
+		if param.Schema != nil {
+			v.addError(location, "schema and content are mutually exclusive")
+		}
+		if len(param.Content) != 1 {
+			v.addError(pointerJoin(location, "content"), "content must have exactly one entry, got %d", len(param.Content))
+		}
+		v.content(pointerJoin(location, "content"), param.Content)
+	}
+}
+
+func (v *docValidator) requestBody(location string, body *RequestBody) {
+	if len(body.Content) == 0 {
+		v.addError(pointerJoin(location, "content"), "no content")
+	}
+	v.content(pointerJoin(location, "content"), body.Content)
+}
+
+func (v *docValidator) response(location string, resp *Response) {
+	if resp.Ref != "" {
+		v.ref(location, resp.Ref, func() error {
+			_, err := v.resolver.Response(resp)
+			return err
+		})
+		return
+	}
+	if resp.Description == "" {
+		v.addError(pointerJoin(location, "description"), "failed on rule required")
+	}
+	for _, key := range sortedParamKeys(resp.Headers) {
+		header := resp.Headers[key]
+		if header.Ref != "" {
+			v.param(pointerJoin(location, "headers", key), header)
+			continue
+		}
+		if header.Schema != nil {
+			v.schema(pointerJoin(location, "headers", key, "schema"), header.Schema)
+		}
+	}
+	v.content(pointerJoin(location, "content"), resp.Content)
+}
+
+func (v *docValidator) content(location string, content mediaTypeMap) {
+	mimeTypes := make([]string, 0, len(content))
+	for mimeType := range content {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	for _, mimeType := range mimeTypes {
+		media := content[mimeType]
+		if media.Schema != nil {
+			v.schema(pointerJoin(location, mimeType, "schema"), media.Schema)
+		}
+		v.encoding(pointerJoin(location, mimeType, "encoding"), media)
+	}
+}
+
+// encoding validates encoding of media type, each key must be a property of the schema
+func (v *docValidator) encoding(location string, media *MediaType) {
+	if len(media.Encoding) == 0 {
+		return
+	}
+	var schema *Schema
+	if media.Schema != nil {
+		schema, _ = v.resolver.Schema(media.Schema)
+	}
+	keys := make([]string, 0, len(media.Encoding))
+	for key := range media.Encoding {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		encoding := media.Encoding[key]
+		if schema != nil && schema.Properties[key] == nil {
+			v.addError(pointerJoin(location, key), "no property %s in schema", key)
+		}
+		if encoding.Style != "" && !encoding.Style.IsValidFor(QueryParam) {
+			v.addError(pointerJoin(location, key, "style"), "style %s is not allowed for encoding", encoding.Style)
+		}
+		for _, name := range sortedParamKeys(encoding.Headers) {
+			header := encoding.Headers[name]
+			if header.Ref != "" {
+				v.param(pointerJoin(location, key, "headers", name), header)
+				continue
+			}
+			if header.Schema != nil {
+				v.schema(pointerJoin(location, key, "headers", name, "schema"), header.Schema)
+			}
+		}
+	}
+}
+
+func (v *docValidator) schema(location string, s *Schema) {
+	if s == nil || v.visiting[s] {
+		return
+	}
+	if s.Ref != "" {
+		v.ref(location, s.Ref, func() error {
+			_, err := v.resolver.Schema(s)
+			return err
+		})
+		return
+	}
+	v.visiting[s] = true
+	defer delete(v.visiting, s)
+
+	if err := validate.Var(s.Format, schemaFormatTag); err != nil {
+		v.addError(pointerJoin(location, "format"), "unknown format %s", s.Format)
+	}
+	if s.Discriminator != nil {
+		v.addStructErrors(pointerJoin(location, "discriminator"), validate.Struct(s.Discriminator))
+	}
+	if s.ExternalDocs != nil {
+		v.addStructErrors(pointerJoin(location, "externalDocs"), validate.Struct(s.ExternalDocs))
+	}
+	for _, key := range sortedSchemaKeys(s.Properties) {
+		v.schema(pointerJoin(location, "properties", key), s.Properties[key])
+	}
+	v.schema(pointerJoin(location, "items"), s.Items)
+	v.schema(pointerJoin(location, "not"), s.Not)
+	v.schema(pointerJoin(location, "additionalProperties"), s.AdditionalProperties)
+	for i, item := range s.AllOf {
+		v.schema(pointerJoin(location, "allOf", strconv.Itoa(i)), item)
+	}
+	for i, item := range s.OneOf {
+		v.schema(pointerJoin(location, "oneOf", strconv.Itoa(i)), item)
+	}
+	for i, item := range s.AnyOf {
+		v.schema(pointerJoin(location, "anyOf", strconv.Itoa(i)), item)
+	}
+}
+
+func (v *docValidator) security(location string, reqs []SecurityRequirement) {
+	for i, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			reqLocation := pointerJoin(location, strconv.Itoa(i), name)
+			var scheme *SecurityScheme
+			if v.root.Components != nil {
+				scheme = v.root.Components.SecuritySchemes[name]
+			}
+			if scheme == nil {
+				v.addError(reqLocation, "no security scheme %s in components", name)
+				continue
+			}
+			if len(req[name]) != 0 && scheme.Type != OAuth2Security && scheme.Type != OpenIDConnectSecurity {
+				v.addError(reqLocation, "scopes are only allowed for oauth2 and openIdConnect")
+			}
+		}
+	}
+}
+
+func (v *docValidator) securityScheme(location string, scheme *SecurityScheme) {
+	v.addStructErrors(location, validate.Struct(scheme))
+	required := func(field, value string) {
+		if value == "" {
+			v.addError(pointerJoin(location, field), "required for security scheme type %s", scheme.Type)
+		}
+	}
+	switch scheme.Type {
+	case APIKeySecurity:
+		required("name", scheme.Name)
+		if scheme.In != QueryParam && scheme.In != HeaderParam && scheme.In != CookieParam {
+			v.addError(pointerJoin(location, "in"), "must be one of query header cookie")
+		}
+	case HTTPSecurity:
+		required("scheme", scheme.Scheme)
+	case OpenIDConnectSecurity:
+		required("openIdConnectUrl", scheme.OpenIDConnectURL)
+	case OAuth2Security:
+		flows := scheme.Flows
+		if flows == nil {
+			v.addError(pointerJoin(location, "flows"), "required for security scheme type %s", scheme.Type)
+			return
+		}
+		// URLs required by each kind of flow
+		for _, flow := range []struct {
+			name          string
+			flow          *OAuthFlow
+			authorization bool
+			token         bool
+		}{
+			{"implicit", flows.Implicit, true, false},
+			{"password", flows.Password, false, true},
+			{"clientCredentials", flows.ClientCredentials, false, true},
+			{"authorizationCode", flows.AuthorizationCode, true, true},
+		} {
+			if flow.flow == nil {
+				continue
+			}
+			flowLocation := pointerJoin(location, "flows", flow.name)
+			if flow.authorization && flow.flow.AuthorizationURL == "" {
+				v.addError(pointerJoin(flowLocation, "authorizationUrl"), "required for %s flow", flow.name)
+			}
+			if flow.token && flow.flow.TokenURL == "" {
+				v.addError(pointerJoin(flowLocation, "tokenUrl"), "required for %s flow", flow.name)
+			}
+			if flow.flow.Scopes == nil {
+				v.addError(pointerJoin(flowLocation, "scopes"), "failed on rule required")
+			}
+		}
+	}
+}
+
+func (v *docValidator) ref(location, ref string, resolve func() error) {
+	if err := resolve(); err != nil {
+		v.addError(pointerJoin(location, "$ref"), "%s", err.Error())
+	}
+}
+
+// pointerJoin append tokens to JSON pointer, escaping them as RFC 6901 requires
+func pointerJoin(pointer string, tokens ...string) string {
+	var b strings.Builder
+	b.WriteString(pointer)
+	for _, token := range tokens {
+		token = strings.Replace(token, "~", "~0", -1)
+		b.WriteString("/" + strings.Replace(token, "/", "~1", -1))
+	}
+	return b.String()
+}
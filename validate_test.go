@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.Route("/books/{id}", func(r Router) {
+		r.GET("", "Get single book", "Info of a book").
+			Metadata("getBook", "Get single book", "Info of a book").
+			WithParam(&Param{Name: "id", In: PathParam}).
+			WithParam(&Param{Ref: "#/components/parameters/missing"}).
+			Returns(200, "Book content", "book", &Book{})
+		r.DELETE("", "Delete book", "Delete a book").
+			Metadata("getBook", "Delete book", "Delete a book").
+			WithPathParam("id", "ID of the book").
+			WithQueryParam("", "Nameless", 1)
+	})
+	o.Info.Version = ""
+	o.Servers = append(o.Servers, Server{})
+	book := o.Components.Schemas["book"]
+	book.Properties["date"].Format = "day"
+	book.Properties["author"] = &Schema{Ref: "#/components/schemas/author"}
+	book.Properties["name"].WithDiscriminator("", nil)
+
+	expect := []string{
+		"/info/version: failed on rule required",
+		"/servers/0/url: failed on rule required",
+		"/paths/~1books~1{id}/get/parameters/0/required: path param must be required",
+		"/paths/~1books~1{id}/get/parameters/1/$ref: failed to resolve $ref #/components/parameters/missing:$ref target not found",
+		"/paths/~1books~1{id}/delete/operationId: duplicate operationId getBook, first used in /paths/~1books~1{id}/get",
+		"/paths/~1books~1{id}/delete/parameters/1/name: failed on rule required",
+		"/paths/~1books~1{id}/delete/responses: no response codes",
+		"/components/schemas/book/properties/author/$ref: failed to resolve $ref #/components/schemas/author:$ref target not found",
+		"/components/schemas/book/properties/date/format: unknown format day",
+		"/components/schemas/book/properties/name/discriminator/propertyName: failed on rule required",
+	}
+	errs := o.Validate()
+	if len(errs) != len(expect) {
+		t.Fatalf("Expect %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expect[i] {
+			t.Errorf("Expect:\n%s\nGot:\n%s", expect[i], err.Error())
+		}
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	o, err := New("3.0.0", sampleInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(o)
+	r.GET("/books/{id}", "Get single book", "Info of a book").
+		WithPathParam("bookId", "ID of the book").
+		Returns(200, "Book content", "book", &Book{})
+
+	expect := []string{
+		"/paths/~1books~1{id}/get: path param bookId is not in path template",
+		"/paths/~1books~1{id}/get: no path param for template variable {id}",
+	}
+	errs := o.Validate()
+	if len(errs) != len(expect) {
+		t.Fatalf("Expect %d errors, got %d: %v", len(expect), len(errs), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expect[i] {
+			t.Errorf("Expect:\n%s\nGot:\n%s", expect[i], err.Error())
+		}
+	}
+}